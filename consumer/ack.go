@@ -0,0 +1,83 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"log/slog"
+	"sync"
+)
+
+// AckFunc deletes the message a consumer function is called for right away and reports whether it was deleted.
+// Calling it again returns the result of the first call.
+type AckFunc func() error
+
+// AckConsumerFn consumes a message and deletes it by calling ack, typically as the last step of the transaction
+// it commits, see StartWithAck.
+type AckConsumerFn func(ctx context.Context, data []byte, attributes map[string]types.MessageAttributeValue, ack AckFunc) error
+
+// StartWithAck consumes the messages with a consumer function deleting them itself, for transactional processing:
+// calling ack within its transaction, right before the commit, rolling back when it fails, the message is deleted
+// only when the transaction is committed. A message is consumed once ack succeeded, whatever consumeFn returns;
+// consumeFn returning nil without calling ack fails with SentinelErrorNotAcknowledged and the message is
+// redelivered. A message whose commit fails after ack succeeded is lost: the transaction must do nothing but
+// commit once ack returned. It requires DeleteStrategyNever, the messages left out of consumption, expired or
+// filtered out for instance, being deleted as usual.
+func (s *SQS) StartWithAck(ctx context.Context, consumeFn AckConsumerFn) error {
+	if s.DeleteStrategy() != DeleteStrategyNever {
+		return fmt.Errorf("%w: StartWithAck requires DeleteStrategyNever", SentinelErrorInvalidConfig)
+	}
+	return s.start(ctx, s.consumeAcked(consumeFn))
+}
+
+func (s *SQS) consumeAcked(consumeFn AckConsumerFn) batchHandler {
+	return s.eachMessage(func(ctx context.Context, w *worker, msg types.Message) bool {
+		return s.consumeOne(ctx, w, msg, func(msgCtx context.Context) error {
+			var once sync.Once
+			var ackErr error
+			acked := false
+			ack := func() error {
+				once.Do(func() {
+					ackErr = s.acknowledge(context.WithoutCancel(msgCtx), msg)
+					acked = ackErr == nil
+				})
+				return ackErr
+			}
+
+			err := consumeFn(msgCtx, []byte(*msg.Body), msg.MessageAttributes, ack)
+			switch {
+			case acked:
+				if err != nil {
+					s.logger().Error("consume function failed after acknowledging its message, which was deleted",
+						slog.String("messageId", aws.ToString(msg.MessageId)),
+						slog.Any("error", err.Error()))
+				}
+				return nil
+			case err == nil && ackErr == nil:
+				return fmt.Errorf("%w: message %s", SentinelErrorNotAcknowledged, aws.ToString(msg.MessageId))
+			case err == nil:
+				return ackErr
+			default:
+				return err
+			}
+		})
+	})
+}
+
+// acknowledge deletes msg right away for an AckFunc.
+func (s *SQS) acknowledge(ctx context.Context, msg types.Message) error {
+	if len(s.deletable([]types.Message{msg})) == 0 {
+		return fmt.Errorf("%w: message %s can't be deleted", SentinelErrorNotAcknowledged, aws.ToString(msg.MessageId))
+	}
+
+	failed, err := s.deleteBatch(ctx, []types.Message{msg})
+	if err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("deleting message %s: %s: %s", aws.ToString(msg.MessageId),
+			aws.ToString(failed[0].Code), aws.ToString(failed[0].Message))
+	}
+	return nil
+}
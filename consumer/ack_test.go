@@ -0,0 +1,66 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func TestSQS_consumeAcked(t *testing.T) {
+	sqsMock := new(SqsMock)
+	sqsMock.On("DeleteMessageBatch", mock.Anything, mock.AnythingOfType("*sqs.DeleteMessageBatchInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+	sqsMock.failDeleteID = "msg3"
+
+	s := &SQS{sqs: sqsMock, config: &SQSConf{Queue: "queue", DeleteStrategy: DeleteStrategyNever, Filter: func(msg Message) bool {
+		return aws.ToString(msg.MessageId) != "filtered"
+	}}}
+
+	ackErrs := map[string]error{}
+	consumeFn := func(_ context.Context, data []byte, _ map[string]types.MessageAttributeValue, ack AckFunc) error {
+		switch string(data) {
+		case "msg1":
+			ackErrs["msg1"] = ack()
+			assert.Equal(t, ackErrs["msg1"], ack())
+			return nil
+		case "msg2":
+			// returns without acknowledging
+			return nil
+		default:
+			ackErrs["msg3"] = ack()
+			return ackErrs["msg3"]
+		}
+	}
+
+	messages := append(getQueueContent().Messages,
+		types.Message{MessageId: aws.String("filtered"), ReceiptHandle: aws.String("handle4"), Body: aws.String("filtered")})
+
+	toDelete, consumed := s.consumeMessages(context.Background(), &worker{}, messages, s.consumeAcked(consumeFn))
+
+	assert.Equal(t, 3, consumed)
+	assert.NoError(t, ackErrs["msg1"])
+	assert.ErrorContains(t, ackErrs["msg3"], "deleting message msg3: InternalError")
+
+	// the filtered out message is left to the usual delete, the acknowledged one was deleted right away
+	assert.Len(t, toDelete, 1)
+	assert.Equal(t, "filtered", aws.ToString(toDelete[0].MessageId))
+	assert.Len(t, sqsMock.deleteInputs, 2)
+	assert.Equal(t, "msg1", aws.ToString(sqsMock.deleteInputs[0].Entries[0].Id))
+
+	st := s.Stats()
+	assert.Equal(t, int64(1), st.ProcessedTotal)
+	assert.Equal(t, int64(2), st.FailedTotal)
+}
+
+func TestSQS_StartWithAckRequiresDeleteStrategyNever(t *testing.T) {
+	s := &SQS{config: &SQSConf{Queue: "queue", DeleteStrategy: DeleteStrategyOnSuccess}}
+
+	err := s.StartWithAck(context.Background(), func(context.Context, []byte, map[string]types.MessageAttributeValue, AckFunc) error {
+		return nil
+	})
+	assert.True(t, errors.Is(err, SentinelErrorInvalidConfig))
+}
@@ -0,0 +1,92 @@
+package consumer
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// defaultQueueVisibilityTimeout is the SQS default, assumed when VisibilityTimeout is not set.
+	defaultQueueVisibilityTimeout = 30 * time.Second
+
+	// adaptiveWeight is the weight of the last consumption in the average consumption duration.
+	adaptiveWeight = 0.2
+)
+
+// adaptive tracks the moving average of the consumption duration of a message.
+type adaptive struct {
+	mu  sync.Mutex
+	avg time.Duration
+}
+
+func (a *adaptive) observe(d time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.avg == 0 {
+		a.avg = d
+		return
+	}
+	a.avg = time.Duration(adaptiveWeight*float64(d) + (1-adaptiveWeight)*float64(a.avg))
+}
+
+func (a *adaptive) average() time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.avg
+}
+
+// observeConsumption records the duration of the consumption of n messages for AdaptiveBatchSize.
+func (s *SQS) observeConsumption(elapsed time.Duration, n int) {
+	if s.config.AdaptiveBatchSize && n > 0 {
+		s.adaptive.observe(elapsed / time.Duration(n))
+	}
+}
+
+// receiveBatchSize returns the MaxNumberOfMessages of the next receive. With AdaptiveBatchSize, it is the number
+// of messages consumed on average within half the visibility timeout, between 1 and MaxNumberOfMessages.
+func (s *SQS) receiveBatchSize() int32 {
+	if !s.config.AdaptiveBatchSize {
+		return s.config.MaxNumberOfMessages
+	}
+
+	avg := s.adaptive.average()
+	if avg == 0 {
+		return s.config.MaxNumberOfMessages
+	}
+
+	n := int64(s.config.visibilityTimeout() / 2 / avg)
+	return int32(max(1, min(n, int64(s.config.MaxNumberOfMessages))))
+}
+
+// waitTime returns the WaitTimeSeconds of the next receive of the worker.
+func (s *SQS) waitTime(w *worker) int32 {
+	if !s.config.AdaptiveWaitTime || w.waitTime == 0 {
+		return s.config.WaitTimeSeconds
+	}
+	return w.waitTime
+}
+
+// adaptWaitTime doubles the WaitTimeSeconds of the worker after an empty receive with AdaptiveWaitTime, up to the
+// 20 seconds SQS allows, and resets it to WaitTimeSeconds once messages are received.
+func (s *SQS) adaptWaitTime(w *worker, empty bool) {
+	if !s.config.AdaptiveWaitTime {
+		return
+	}
+
+	if !empty {
+		w.waitTime = 0
+		return
+	}
+	w.waitTime = min(max(s.waitTime(w), 1)*2, maxWaitTimeSeconds)
+}
+
+// visibilityTimeout is the visibility timeout of the received messages, assuming the queue uses the SQS default
+// when VisibilityTimeout is not set.
+func (c *SQSConf) visibilityTimeout() time.Duration {
+	if c.VisibilityTimeout > 0 {
+		return time.Duration(c.VisibilityTimeout) * time.Second
+	}
+	return defaultQueueVisibilityTimeout
+}
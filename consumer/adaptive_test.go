@@ -0,0 +1,101 @@
+package consumer
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestSQS_receiveBatchSize(t *testing.T) {
+	tests := []struct {
+		name       string
+		adaptive   bool
+		visibility int32
+		durations  []time.Duration
+		want       int32
+	}{
+		{name: "shouldKeepMaxWhenDisabled", durations: []time.Duration{time.Minute}, want: 10},
+		{name: "shouldKeepMaxWithoutObservation", adaptive: true, want: 10},
+		{name: "shouldKeepMaxForFastConsumption", adaptive: true, durations: []time.Duration{time.Millisecond}, want: 10},
+		{name: "shouldLowerForSlowConsumption", adaptive: true, visibility: 60, durations: []time.Duration{10 * time.Second}, want: 3},
+		{name: "shouldReceiveAtLeastOne", adaptive: true, durations: []time.Duration{time.Minute}, want: 1},
+		{
+			name:      "shouldAverageConsumptions",
+			adaptive:  true,
+			durations: []time.Duration{time.Second, 11 * time.Second},
+			want:      5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &SQS{config: &SQSConf{
+				MaxNumberOfMessages: 10,
+				VisibilityTimeout:   tt.visibility,
+				AdaptiveBatchSize:   tt.adaptive,
+			}}
+
+			for _, d := range tt.durations {
+				s.observeConsumption(d, 1)
+			}
+
+			assert.Equal(t, tt.want, s.receiveBatchSize())
+		})
+	}
+}
+
+// receiveSequenceMock returns the queue content on the receives whose index is in nonEmpty, nothing otherwise,
+// and records the WaitTimeSeconds of every receive.
+type receiveSequenceMock struct {
+	*SqsMock
+	nonEmpty map[int]bool
+	waits    []int32
+}
+
+func (m *receiveSequenceMock) ReceiveMessage(_ context.Context, params *sqs.ReceiveMessageInput, _ ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+	defer func() { m.waits = append(m.waits, params.WaitTimeSeconds) }()
+	if m.nonEmpty[len(m.waits)] {
+		return getQueueContent(), nil
+	}
+	return &sqs.ReceiveMessageOutput{}, nil
+}
+
+func TestSQS_AdaptiveWaitTime(t *testing.T) {
+	tests := []struct {
+		name      string
+		adaptive  bool
+		wantWaits []int32
+	}{
+		{name: "shouldKeepWaitTimeByDefault", wantWaits: []int32{2, 2, 2, 2, 2, 2, 2, 2}},
+		{name: "shouldLengthenWhenEmpty", adaptive: true, wantWaits: []int32{2, 4, 8, 16, 20, 20, 2, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqsMock := &receiveSequenceMock{SqsMock: new(SqsMock), nonEmpty: map[int]bool{5: true, 6: true}}
+			sqsMock.On("DeleteMessageBatch", mock.Anything, mock.AnythingOfType("*sqs.DeleteMessageBatchInput"),
+				mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+
+			s := &SQS{sqs: sqsMock, clock: &fakeClock{now: time.Unix(1700000000, 0)}, config: &SQSConf{
+				Queue:            "queue",
+				DeleteStrategy:   DeleteStrategyOnSuccess,
+				WaitTimeSeconds:  2,
+				AdaptiveWaitTime: tt.adaptive,
+			}}
+
+			consumeFn := func(_ context.Context, _ []byte, _ map[string]types.MessageAttributeValue) error { return nil }
+			w := &worker{}
+			for range tt.wantWaits {
+				_, err := s.pollCycle(context.Background(), s.consumeEach(consumeFn), w)
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.wantWaits, sqsMock.waits)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package consumer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"time"
+)
+
+// receiveAttemptWindow is how long SQS deduplicates the receives of a FIFO queue sharing a ReceiveRequestAttemptId.
+const receiveAttemptWindow = 5 * time.Minute
+
+type receiveAttemptKey struct{}
+
+// ReceiveRequestAttemptID returns the ReceiveRequestAttemptId of the receive of the message a consumer function
+// is called for, set on the receives of FIFO queues only.
+func ReceiveRequestAttemptID(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(receiveAttemptKey{}).(string)
+	return id, ok
+}
+
+// NewReceiveRequestAttemptID is the default ReceiveRequestAttemptIDFunc, returning 32 random hexadecimal digits.
+func NewReceiveRequestAttemptID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// receiveAttempt returns the ReceiveRequestAttemptId of the next receive of the worker on a FIFO queue: the one of
+// its previous receive when it failed less than receiveAttemptWindow ago, so that SQS returns the messages the
+// failed receive may have made invisible, a new one otherwise.
+func (s *SQS) receiveAttempt(w *worker) *string {
+	if !s.config.FIFO {
+		return nil
+	}
+
+	now := s.now()
+	if !w.attemptFailed || now.Sub(w.attemptStarted) >= receiveAttemptWindow {
+		newID := s.config.ReceiveRequestAttemptIDFunc
+		if newID == nil {
+			newID = NewReceiveRequestAttemptID
+		}
+		w.attemptID = newID()
+		w.attemptStarted = now
+	}
+	return aws.String(w.attemptID)
+}
@@ -0,0 +1,80 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestSQS_receiveAttempt(t *testing.T) {
+	sqsMock := new(SqsMock)
+	sqsMock.On("ReceiveMessage", mock.Anything, mock.AnythingOfType("*sqs.ReceiveMessageInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, &types.OverLimit{}).Once()
+	sqsMock.On("ReceiveMessage", mock.Anything, mock.AnythingOfType("*sqs.ReceiveMessageInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+	sqsMock.On("DeleteMessageBatch", mock.Anything, mock.AnythingOfType("*sqs.DeleteMessageBatchInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+
+	ids := 0
+	var consumedWith []string
+	s := &SQS{
+		sqs: sqsMock,
+		config: &SQSConf{
+			Queue:              "queue.fifo",
+			FIFO:               true,
+			DeleteStrategy:     DeleteStrategyOnSuccess,
+			ThrottleBackoff:    time.Second,
+			MaxThrottleBackoff: time.Second,
+			ReceiveRequestAttemptIDFunc: func() string {
+				ids++
+				return fmt.Sprintf("attempt-%d", ids)
+			},
+		},
+		clock: &fakeClock{now: time.Unix(1700000000, 0)},
+	}
+
+	consumeFn := s.consumeEach(func(ctx context.Context, _ []byte, _ map[string]types.MessageAttributeValue) error {
+		id, _ := ReceiveRequestAttemptID(ctx)
+		consumedWith = append(consumedWith, id)
+		return nil
+	})
+
+	w := &worker{}
+	for i := 0; i < 3; i++ {
+		_, err := s.pollCycle(context.Background(), consumeFn, w)
+		require.NoError(t, err)
+	}
+
+	var attempts []string
+	for _, input := range sqsMock.inputs {
+		attempts = append(attempts, aws.ToString(input.ReceiveRequestAttemptId))
+	}
+	assert.Equal(t, []string{"attempt-1", "attempt-1", "attempt-2"}, attempts)
+	assert.Equal(t, []string{"attempt-1", "attempt-1", "attempt-1", "attempt-2", "attempt-2", "attempt-2"}, consumedWith)
+}
+
+func TestSQS_receiveAttemptStandardQueue(t *testing.T) {
+	s := &SQS{config: &SQSConf{Queue: "queue"}}
+	assert.Nil(t, s.receiveAttempt(&worker{attemptFailed: true}))
+}
+
+func TestSQS_receiveAttemptExpired(t *testing.T) {
+	clk := &fakeClock{now: time.Unix(1700000000, 0)}
+	s := &SQS{config: &SQSConf{Queue: "queue.fifo", FIFO: true}, clock: clk}
+
+	w := &worker{}
+	first := aws.ToString(s.receiveAttempt(w))
+	assert.Len(t, first, 32)
+
+	w.attemptFailed = true
+	assert.Equal(t, first, aws.ToString(s.receiveAttempt(w)))
+
+	clk.now = clk.now.Add(receiveAttemptWindow)
+	assert.NotEqual(t, first, aws.ToString(s.receiveAttempt(w)))
+}
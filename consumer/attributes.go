@@ -0,0 +1,107 @@
+package consumer
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"log/slog"
+	"slices"
+)
+
+// fifoSystemAttributes are the system attributes of the messages of FIFO queues.
+var fifoSystemAttributes = []types.MessageSystemAttributeName{
+	types.MessageSystemAttributeNameMessageGroupId,
+	types.MessageSystemAttributeNameSequenceNumber,
+	types.MessageSystemAttributeNameMessageDeduplicationId,
+}
+
+// requiredSystemAttributes lists the system attributes read by the enabled features.
+func (s *SQS) requiredSystemAttributes() map[types.MessageSystemAttributeName]string {
+	required := map[types.MessageSystemAttributeName]string{}
+
+	if s.config.TTLAttribute != "" {
+		required[types.MessageSystemAttributeNameSentTimestamp] = "TTLAttribute"
+	}
+
+	if !s.config.MinSentTimestamp.IsZero() {
+		required[types.MessageSystemAttributeNameSentTimestamp] = "MinSentTimestamp"
+	}
+
+	if s.config.Metrics != nil {
+		required[types.MessageSystemAttributeNameSentTimestamp] = "Metrics"
+	}
+
+	if s.config.AuditWriter != nil {
+		required[types.MessageSystemAttributeNameApproximateReceiveCount] = "AuditWriter"
+	}
+
+	if s.config.ShouldTrace != nil {
+		required[types.MessageSystemAttributeNameApproximateReceiveCount] = "ShouldTrace"
+	}
+
+	if s.config.PartitionByGroup && s.config.GroupKeyExtractor == nil {
+		required[types.MessageSystemAttributeNameMessageGroupId] = "PartitionByGroup"
+	}
+
+	if s.config.FIFO {
+		for _, name := range fifoSystemAttributes {
+			required[name] = "FIFO"
+		}
+	}
+
+	return required
+}
+
+// checkSystemAttributes adds the system attributes required by the enabled features
+// to the ones the user restricted the receive requests to.
+func (s *SQS) checkSystemAttributes() {
+	names := s.config.SystemAttributeNames
+	if len(names) == 0 || slices.Contains(names, types.MessageSystemAttributeNameAll) {
+		return
+	}
+
+	for name, feature := range s.requiredSystemAttributes() {
+		if slices.Contains(names, name) {
+			continue
+		}
+		s.logger().Warn("system attribute required by an enabled feature is not requested, adding it",
+			slog.String("attribute", string(name)), slog.String("feature", feature))
+		names = append(names, name)
+	}
+
+	s.config.SystemAttributeNames = names
+}
+
+// systemAttributeNames defaults to All, to the attributes required by the enabled features in FIFO mode.
+func (s *SQS) systemAttributeNames() []types.MessageSystemAttributeName {
+	if len(s.config.SystemAttributeNames) > 0 {
+		return s.config.SystemAttributeNames
+	}
+
+	if s.config.FIFO {
+		names := make([]types.MessageSystemAttributeName, 0, len(fifoSystemAttributes))
+		for name := range s.requiredSystemAttributes() {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		return names
+	}
+
+	return []types.MessageSystemAttributeName{types.MessageSystemAttributeNameAll}
+}
+
+// checkFIFOAttributes logs the received messages missing a FIFO system attribute, in FIFO mode.
+func (s *SQS) checkFIFOAttributes(msgs []types.Message) {
+	if !s.config.FIFO {
+		return
+	}
+
+	for _, msg := range msgs {
+		for _, name := range fifoSystemAttributes {
+			if _, ok := msg.Attributes[string(name)]; !ok {
+				s.logger().Warn("FIFO system attribute missing from received message",
+					slog.String("messageId", aws.ToString(msg.MessageId)),
+					slog.String("attribute", string(name)))
+			}
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package consumer
+
+import (
+	"bytes"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"testing"
+)
+
+func TestSQS_checkSystemAttributes(t *testing.T) {
+	tests := []struct {
+		name string
+		conf *SQSConf
+		want []types.MessageSystemAttributeName
+	}{
+		{
+			name: "shouldRequestAllByDefault",
+			conf: &SQSConf{TTLAttribute: "ttl"},
+			want: []types.MessageSystemAttributeName{types.MessageSystemAttributeNameAll},
+		},
+		{
+			name: "shouldKeepSubset",
+			conf: &SQSConf{SystemAttributeNames: []types.MessageSystemAttributeName{types.MessageSystemAttributeNameApproximateReceiveCount}},
+			want: []types.MessageSystemAttributeName{types.MessageSystemAttributeNameApproximateReceiveCount},
+		},
+		{
+			name: "shouldAddRequiredAttribute",
+			conf: &SQSConf{
+				TTLAttribute:         "ttl",
+				SystemAttributeNames: []types.MessageSystemAttributeName{types.MessageSystemAttributeNameApproximateReceiveCount},
+			},
+			want: []types.MessageSystemAttributeName{
+				types.MessageSystemAttributeNameApproximateReceiveCount,
+				types.MessageSystemAttributeNameSentTimestamp,
+			},
+		},
+		{
+			name: "shouldRequestFIFOAttributes",
+			conf: &SQSConf{FIFO: true, TTLAttribute: "ttl"},
+			want: []types.MessageSystemAttributeName{
+				types.MessageSystemAttributeNameMessageDeduplicationId,
+				types.MessageSystemAttributeNameMessageGroupId,
+				types.MessageSystemAttributeNameSentTimestamp,
+				types.MessageSystemAttributeNameSequenceNumber,
+			},
+		},
+		{
+			name: "shouldRequestObservabilityAttributes",
+			conf: &SQSConf{FIFO: true, Metrics: noopRecorder{}, AuditWriter: &bytes.Buffer{}},
+			want: []types.MessageSystemAttributeName{
+				types.MessageSystemAttributeNameApproximateReceiveCount,
+				types.MessageSystemAttributeNameMessageDeduplicationId,
+				types.MessageSystemAttributeNameMessageGroupId,
+				types.MessageSystemAttributeNameSentTimestamp,
+				types.MessageSystemAttributeNameSequenceNumber,
+			},
+		},
+		{
+			name: "shouldAddFIFOAttributes",
+			conf: &SQSConf{FIFO: true, SystemAttributeNames: []types.MessageSystemAttributeName{types.MessageSystemAttributeNameSenderId}},
+			want: []types.MessageSystemAttributeName{
+				types.MessageSystemAttributeNameSenderId,
+				types.MessageSystemAttributeNameMessageGroupId,
+				types.MessageSystemAttributeNameSequenceNumber,
+				types.MessageSystemAttributeNameMessageDeduplicationId,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &SQS{config: tt.conf}
+			s.checkSystemAttributes()
+			assert.ElementsMatch(t, tt.want, s.systemAttributeNames())
+		})
+	}
+}
+
+func TestSQS_checkFIFOAttributes(t *testing.T) {
+	var buf bytes.Buffer
+	s := &SQS{config: &SQSConf{FIFO: true, Logger: slog.New(slog.NewTextHandler(&buf, nil))}}
+
+	s.checkFIFOAttributes([]types.Message{
+		{MessageId: aws.String("msg1"), Attributes: map[string]string{
+			"MessageGroupId":         "group",
+			"SequenceNumber":         "1",
+			"MessageDeduplicationId": "dedup",
+		}},
+		{MessageId: aws.String("msg2"), Attributes: map[string]string{"MessageGroupId": "group"}},
+	})
+
+	assert.NotContains(t, buf.String(), "messageId=msg1")
+	assert.Contains(t, buf.String(), "messageId=msg2 attribute=SequenceNumber")
+	assert.Contains(t, buf.String(), "messageId=msg2 attribute=MessageDeduplicationId")
+}
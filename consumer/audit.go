@@ -0,0 +1,99 @@
+package consumer
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"io"
+	"log/slog"
+	"time"
+)
+
+const (
+	AuditOutcomeSuccess   = AuditOutcome("SUCCESS")
+	AuditOutcomeFailure   = AuditOutcome("FAILURE")
+	AuditOutcomeCancelled = AuditOutcome("CANCELLED")
+
+	// auditBufferSize is the number of records waiting to be written before new ones are dropped.
+	auditBufferSize = 1024
+)
+
+type AuditOutcome string
+
+// AuditRecord is the line written to AuditWriter for every consumed message.
+type AuditRecord struct {
+	Time         time.Time    `json:"time"`
+	MessageID    string       `json:"messageId"`
+	Queue        string       `json:"queue"`
+	Consumer     string       `json:"consumer"`
+	ReceiveCount int          `json:"receiveCount"`
+	DurationMs   float64      `json:"durationMs"`
+	Outcome      AuditOutcome `json:"outcome"`
+	Error        string       `json:"error,omitempty"`
+}
+
+// auditLog writes the records from a goroutine so that a slow AuditWriter doesn't block the consumption.
+type auditLog struct {
+	records chan AuditRecord
+	done    chan struct{}
+}
+
+func newAuditLog(w io.Writer, logger *slog.Logger) *auditLog {
+	a := &auditLog{
+		records: make(chan AuditRecord, auditBufferSize),
+		done:    make(chan struct{}),
+	}
+
+	go func() {
+		defer close(a.done)
+
+		buf := bufio.NewWriter(w)
+		enc := json.NewEncoder(buf)
+		for r := range a.records {
+			if err := enc.Encode(r); err != nil {
+				logger.Error("error writing audit record", slog.Any("error", err.Error()))
+			}
+			// flush once the pending records are written
+			if len(a.records) == 0 {
+				if err := buf.Flush(); err != nil {
+					logger.Error("error writing audit record", slog.Any("error", err.Error()))
+				}
+			}
+		}
+	}()
+
+	return a
+}
+
+// close writes the pending records and waits for the writer goroutine.
+func (a *auditLog) close() {
+	close(a.records)
+	<-a.done
+}
+
+// audit records the consumption of msg, dropping the record when the buffer is full.
+func (s *SQS) audit(msg types.Message, elapsed time.Duration, outcome AuditOutcome, err error) {
+	if s.auditLog == nil {
+		return
+	}
+
+	r := AuditRecord{
+		Time:         s.now(),
+		MessageID:    aws.ToString(msg.MessageId),
+		Queue:        s.queueName(),
+		Consumer:     s.name(),
+		ReceiveCount: s.message(msg).ApproximateReceiveCount,
+		DurationMs:   float64(elapsed) / float64(time.Millisecond),
+		Outcome:      outcome,
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+
+	select {
+	case s.auditLog.records <- r:
+	default:
+		s.logger().Warn("audit buffer full, dropping record", slog.String("messageId", r.MessageID))
+	}
+}
@@ -0,0 +1,56 @@
+package consumer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestSQS_AuditWriter(t *testing.T) {
+	sqsMock := new(SqsMock)
+	sqsMock.On("ReceiveMessage", mock.Anything, mock.AnythingOfType("*sqs.ReceiveMessageInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+	sqsMock.On("DeleteMessageBatch", mock.Anything, mock.AnythingOfType("*sqs.DeleteMessageBatchInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+
+	var buf bytes.Buffer
+	s := &SQS{sqs: sqsMock, config: &SQSConf{
+		Queue:          "https://sqs.eu-west-1.amazonaws.com/123456789012/orders",
+		Concurrency:    1,
+		DeleteStrategy: DeleteStrategyOnSuccess,
+		AuditWriter:    &buf,
+	}}
+
+	err := s.StartWithContext(context.Background(), func(_ context.Context, data []byte, attributes map[string]types.MessageAttributeValue) error {
+		switch string(data) {
+		case "msg2":
+			return errors.New("fake consume error")
+		case "msg3":
+			s.Stop()
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+
+	records := make([]AuditRecord, len(lines))
+	for i, line := range lines {
+		require.NoError(t, json.Unmarshal([]byte(line), &records[i]))
+		assert.Equal(t, "orders", records[i].Queue)
+	}
+
+	assert.Equal(t, "msg1", records[0].MessageID)
+	assert.Equal(t, AuditOutcomeSuccess, records[0].Outcome)
+	assert.Equal(t, AuditOutcomeFailure, records[1].Outcome)
+	assert.Equal(t, "fake consume error", records[1].Error)
+	assert.Equal(t, AuditOutcomeSuccess, records[2].Outcome)
+}
@@ -0,0 +1,47 @@
+package consumer
+
+import "time"
+
+// BackpressureRecorder is implemented by the MetricsRecorder also recording how far the consumption lags behind
+// the receives, see SQS.WorkQueueDepth and Stats.PollerBlockedTotal.
+type BackpressureRecorder interface {
+	// WorkQueueDepth is called after the messages of a receive were dispatched to the group partitions.
+	WorkQueueDepth(queue string, depth int)
+	// PollerBlocked is called with how long a worker waited for a free partition or MaxInFlight slot.
+	PollerBlocked(queue string, waited time.Duration)
+}
+
+// WorkQueueDepth returns the number of messages dispatched to the group partitions of PartitionByGroup and not
+// consumed yet, zero without partitions. Along with Stats.PollerBlockedTotal, it tells whether the messages are
+// received faster than they are consumed, to tune Concurrency, MaxNumberOfMessages and MaxInFlight.
+func (s *SQS) WorkQueueDepth() int {
+	p := s.workQueue.Load()
+	if p == nil {
+		return 0
+	}
+
+	depth := 0
+	for _, partition := range *p {
+		depth += len(partition)
+	}
+	return depth
+}
+
+// waitStarted records a worker starting to wait for a free partition or MaxInFlight slot, the returned function
+// recording the end of the wait.
+func (s *SQS) waitStarted() func() {
+	started := s.now()
+	return func() {
+		s.stats.pollerBlocked.Add(1)
+		if r, ok := s.metrics().(BackpressureRecorder); ok {
+			r.PollerBlocked(s.queueName(), s.since(started))
+		}
+	}
+}
+
+// recordWorkQueueDepth records the WorkQueueDepth once a receive was dispatched to the partitions.
+func (s *SQS) recordWorkQueueDepth() {
+	if r, ok := s.metrics().(BackpressureRecorder); ok {
+		r.WorkQueueDepth(s.queueName(), s.WorkQueueDepth())
+	}
+}
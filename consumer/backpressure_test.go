@@ -0,0 +1,96 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sync/semaphore"
+	"sync"
+	"testing"
+	"time"
+)
+
+type backpressureRecorder struct {
+	fakeRecorder
+	mu      sync.Mutex
+	depths  []int
+	blocked int
+}
+
+func (r *backpressureRecorder) WorkQueueDepth(queue string, depth int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.depths = append(r.depths, depth)
+}
+
+func (r *backpressureRecorder) PollerBlocked(queue string, waited time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.blocked++
+}
+
+func TestSQS_WorkQueueDepth(t *testing.T) {
+	s := &SQS{config: &SQSConf{}}
+	assert.Zero(t, s.WorkQueueDepth())
+
+	p := newPartitions(2)
+	s.workQueue.Store(&p)
+	p[0] <- func() {}
+	p[1] <- func() {}
+	p[1] <- func() {}
+	assert.Equal(t, 3, s.WorkQueueDepth())
+}
+
+func TestPartitions_consumeBlocked(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// an unbuffered partition whose goroutine only starts once the first dispatch blocked
+	p := partitions{make(partition)}
+	var start sync.Once
+	blocked := 0
+	onBlocked := func() func() {
+		start.Do(func() {
+			go func() { _ = p[0].run(ctx) }()
+		})
+		return func() { blocked++ }
+	}
+
+	messages := make([]types.Message, 3)
+	for i := range messages {
+		messages[i] = types.Message{MessageId: aws.String(fmt.Sprintf("msg%d", i))}
+	}
+
+	s := &SQS{config: &SQSConf{}}
+	consumed := p.consume(ctx, messages, s.groupKey, func(msg types.Message) *types.Message {
+		return &msg
+	}, onBlocked)
+
+	assert.Len(t, consumed, 3)
+	assert.GreaterOrEqual(t, blocked, 1)
+}
+
+func TestSQS_acquireBlocked(t *testing.T) {
+	recorder := &backpressureRecorder{}
+	s := &SQS{
+		config:   &SQSConf{Queue: "queue", Metrics: recorder},
+		inFlight: semaphore.NewWeighted(1),
+	}
+
+	assert.True(t, s.acquire(context.Background(), 1))
+	assert.Zero(t, s.Stats().PollerBlockedTotal)
+
+	acquired := make(chan bool)
+	go func() {
+		acquired <- s.acquire(context.Background(), 1)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	s.release(1)
+
+	assert.True(t, <-acquired)
+	assert.Equal(t, int64(1), s.Stats().PollerBlockedTotal)
+	assert.Equal(t, 1, recorder.blocked)
+}
@@ -0,0 +1,104 @@
+package consumer
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	CircuitClosed   = CircuitState("CLOSED")
+	CircuitOpen     = CircuitState("OPEN")
+	CircuitHalfOpen = CircuitState("HALF_OPEN")
+)
+
+type CircuitState string
+
+// circuitBreaker reports whether polling can be paused by the circuit breaker.
+func (c *SQSConf) circuitBreaker() bool {
+	return c.CircuitBreakerThreshold > 0 || c.PanicPolicy == PanicPolicyCircuit
+}
+
+// breaker counts consecutive consumer function failures. Once open, polling pauses for the cooldown,
+// then a single worker polls again to probe whether the consumer function recovered.
+type breaker struct {
+	mu       sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// allow returns how long the calling worker must wait before polling, zero meaning it may poll now.
+func (b *breaker) allow(now time.Time, cooldown time.Duration) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if remaining := cooldown - now.Sub(b.openedAt); remaining > 0 {
+			return remaining
+		}
+		b.state = CircuitHalfOpen
+		b.probing = true
+		return 0
+	case CircuitHalfOpen:
+		if b.probing {
+			return time.Second
+		}
+		b.probing = true
+		return 0
+	default:
+		return 0
+	}
+}
+
+// release ends the poll cycle of the probing worker.
+func (b *breaker) release() {
+	b.mu.Lock()
+	b.probing = false
+	b.mu.Unlock()
+}
+
+// success records a consume function success, closing a half-open breaker. An open breaker stays open for its
+// cooldown, the success being the one of a message in flight when it tripped.
+func (b *breaker) success() {
+	b.mu.Lock()
+	b.failures = 0
+	if b.state != CircuitOpen {
+		b.state = CircuitClosed
+	}
+	b.mu.Unlock()
+}
+
+// failure records a consume function failure, a threshold of zero only reopening a half-open breaker.
+func (b *breaker) failure(now time.Time, threshold int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.state == CircuitHalfOpen || (threshold > 0 && b.state != CircuitOpen && b.failures >= threshold) {
+		b.trip(now)
+	}
+}
+
+// open trips the breaker whatever the failure count.
+func (b *breaker) open(now time.Time) {
+	b.mu.Lock()
+	b.trip(now)
+	b.mu.Unlock()
+}
+
+func (b *breaker) trip(now time.Time) {
+	b.state = CircuitOpen
+	b.openedAt = now
+}
+
+func (b *breaker) current() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == "" {
+		return CircuitClosed
+	}
+	return b.state
+}
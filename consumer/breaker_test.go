@@ -0,0 +1,40 @@
+package consumer
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	b := &breaker{}
+	now := time.Now()
+	cooldown := 20 * time.Millisecond
+
+	assert.Zero(t, b.allow(now, cooldown))
+	b.failure(now, 2)
+	assert.Equal(t, CircuitClosed, b.current())
+	b.failure(now, 2)
+	assert.Equal(t, CircuitOpen, b.current())
+	assert.NotZero(t, b.allow(now, cooldown))
+
+	b.success()
+	assert.Equal(t, CircuitOpen, b.current(), "a message in flight when the circuit opened keeps it open")
+	assert.NotZero(t, b.allow(now, cooldown))
+
+	now = now.Add(cooldown)
+	assert.Zero(t, b.allow(now, cooldown))
+	assert.Equal(t, CircuitHalfOpen, b.current())
+	assert.NotZero(t, b.allow(now, cooldown), "only one worker probes while half open")
+
+	b.failure(now, 2)
+	assert.Equal(t, CircuitOpen, b.current(), "a failed probe opens the circuit again")
+	b.release()
+
+	now = now.Add(cooldown)
+	assert.Zero(t, b.allow(now, cooldown))
+	b.success()
+	b.release()
+	assert.Equal(t, CircuitClosed, b.current())
+	assert.Zero(t, b.allow(now, cooldown))
+}
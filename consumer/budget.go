@@ -0,0 +1,86 @@
+package consumer
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+)
+
+type retryBudgetKey struct{}
+
+// tokenBucket holds tokens refilled at a rate per second up to a burst.
+type tokenBucket struct {
+	tokens float64
+	filled time.Time
+}
+
+// retryBudget is the token bucket of RetryBudgetRate shared by the workers.
+type retryBudget struct {
+	mu sync.Mutex
+	tokenBucket
+	// exhausted is set from the first retry denied until a token is taken again
+	exhausted bool
+}
+
+// AllowRetry takes a token from the retry budget of the consumer, see SQSConf.RetryBudgetRate, and reports whether
+// the consumer function may retry the message it is called for in-process. Once it returns false, the consumer
+// function must return its error, leaving the message to SQS redelivery. It always returns true without a budget.
+func AllowRetry(ctx context.Context) bool {
+	take, ok := ctx.Value(retryBudgetKey{}).(func() bool)
+	return !ok || take()
+}
+
+func (c *SQSConf) retryBudgetBurst() float64 {
+	if c.RetryBudgetBurst > 0 {
+		return float64(c.RetryBudgetBurst)
+	}
+	return math.Max(1, c.RetryBudgetRate)
+}
+
+// refill adds the tokens earned since the last refill, a full bucket on first use.
+func (b *tokenBucket) refill(now time.Time, rate, burst float64) {
+	if b.filled.IsZero() {
+		b.tokens = burst
+	} else {
+		b.tokens = math.Min(burst, b.tokens+now.Sub(b.filled).Seconds()*rate)
+	}
+	b.filled = now
+}
+
+// takeRetryToken spends a token of the retry budget, warning when the budget gets exhausted.
+func (s *SQS) takeRetryToken() bool {
+	b := &s.retryBudget
+	b.mu.Lock()
+	b.refill(s.now(), s.config.RetryBudgetRate, s.config.retryBudgetBurst())
+	if b.tokens >= 1 {
+		b.tokens--
+		b.exhausted = false
+		b.mu.Unlock()
+		return true
+	}
+	warn := !b.exhausted
+	b.exhausted = true
+	b.mu.Unlock()
+
+	s.stats.retryBudgetExhausted.Add(1)
+	if warn {
+		s.logger().Warn("retry budget exhausted, failed messages are left to redelivery",
+			slog.Float64("rate", s.config.RetryBudgetRate))
+	}
+	return false
+}
+
+// retryBudgetTokens returns the tokens left in the retry budget, zero without one.
+func (s *SQS) retryBudgetTokens() float64 {
+	if s.config.RetryBudgetRate == 0 {
+		return 0
+	}
+
+	b := &s.retryBudget
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(s.now(), s.config.RetryBudgetRate, s.config.retryBudgetBurst())
+	return b.tokens
+}
@@ -0,0 +1,51 @@
+package consumer
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestSQS_takeRetryToken(t *testing.T) {
+	clk := &fakeClock{now: time.Unix(1700000000, 0)}
+	s := &SQS{config: &SQSConf{RetryBudgetRate: 2, RetryBudgetBurst: 3}, clock: clk}
+
+	for i := 0; i < 3; i++ {
+		assert.True(t, s.takeRetryToken())
+	}
+	assert.False(t, s.takeRetryToken())
+	assert.False(t, s.takeRetryToken())
+	assert.Equal(t, int64(2), s.Stats().RetryBudgetExhaustedTotal)
+
+	clk.now = clk.now.Add(time.Second)
+	assert.Equal(t, 2.0, s.Stats().RetryBudgetTokens)
+	assert.True(t, s.takeRetryToken())
+	assert.True(t, s.takeRetryToken())
+	assert.False(t, s.takeRetryToken())
+
+	clk.now = clk.now.Add(time.Hour)
+	assert.Equal(t, 3.0, s.Stats().RetryBudgetTokens)
+}
+
+func TestAllowRetry(t *testing.T) {
+	assert.True(t, AllowRetry(context.Background()))
+
+	s := &SQS{
+		config: &SQSConf{Queue: "queue", DeleteStrategy: DeleteStrategyOnSuccess, RetryBudgetRate: 0.5},
+		clock:  &fakeClock{now: time.Unix(1700000000, 0)},
+	}
+
+	var allowed []bool
+	consumeFn := func(ctx context.Context, _ []byte, _ map[string]types.MessageAttributeValue) error {
+		allowed = append(allowed, AllowRetry(ctx), AllowRetry(ctx))
+		return nil
+	}
+
+	messages := []types.Message{{MessageId: aws.String("msg1"), Body: aws.String("body")}}
+	s.consumeMessages(context.Background(), &worker{}, messages, s.consumeEach(consumeFn))
+
+	assert.Equal(t, []bool{true, false}, allowed)
+}
@@ -0,0 +1,38 @@
+package consumer
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"log/slog"
+)
+
+// BodyDecoder transcodes a message body to UTF-8. It is called concurrently by the workers. The decoders of
+// golang.org/x/text/encoding are not safe for concurrent use, so a new one is created for every body:
+//
+//	func(body []byte) ([]byte, error) {
+//		return charmap.Windows1252.NewDecoder().Bytes(body)
+//	}
+type BodyDecoder func(body []byte) ([]byte, error)
+
+// transcode replaces the body of msg with its BodyCharset transcoding.
+func (s *SQS) transcode(msg *types.Message) error {
+	body, err := s.config.BodyCharset([]byte(aws.ToString(msg.Body)))
+	if err != nil {
+		return err
+	}
+
+	msg.Body = aws.String(string(body))
+	return nil
+}
+
+// skipUntranscodable applies BodyCharsetErrorAction, DecodeErrorAction by default, to a message whose body failed
+// to transcode and reports whether the message must be deleted.
+func (s *SQS) skipUntranscodable(ctx context.Context, msg types.Message, err error) bool {
+	action := s.config.BodyCharsetErrorAction
+	if action == "" {
+		action = s.config.decodeErrorAction()
+	}
+
+	return s.skip(ctx, msg, action, "skipping message failing to transcode to UTF-8", slog.Any("error", err.Error()))
+}
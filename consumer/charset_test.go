@@ -0,0 +1,65 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// latin1 decodes ISO 8859-1, rejecting the C1 control codes to simulate invalid input.
+func latin1(body []byte) ([]byte, error) {
+	runes := make([]rune, len(body))
+	for i, b := range body {
+		if b >= 0x80 && b < 0xa0 {
+			return nil, errors.New("invalid byte")
+		}
+		runes[i] = rune(b)
+	}
+	return []byte(string(runes)), nil
+}
+
+func TestSQS_consumeMessagesBodyCharset(t *testing.T) {
+	messages := []types.Message{
+		{MessageId: aws.String("latin1"), Body: aws.String("caf\xe9")},
+		{MessageId: aws.String("invalid"), Body: aws.String("\x81")},
+	}
+
+	tests := []struct {
+		name         string
+		action       DecodeErrorAction
+		wantConsumed []string
+		wantDelete   []string
+	}{
+		{name: "shouldDeleteInvalidByDefault", wantConsumed: []string{"café"}, wantDelete: []string{"invalid", "latin1"}},
+		{name: "shouldKeepInvalid", action: DecodeErrorKeep, wantConsumed: []string{"café"}, wantDelete: []string{"latin1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &SQS{config: &SQSConf{
+				Queue:                  "queue",
+				DeleteStrategy:         DeleteStrategyOnSuccess,
+				BodyCharset:            latin1,
+				BodyCharsetErrorAction: tt.action,
+			}}
+
+			var consumed []string
+			consumeFn := func(_ context.Context, data []byte, _ map[string]types.MessageAttributeValue) error {
+				consumed = append(consumed, string(data))
+				return nil
+			}
+
+			toDelete, _ := s.consumeMessages(context.Background(), &worker{}, messages, s.consumeEach(consumeFn))
+
+			ids := make([]string, len(toDelete))
+			for i, msg := range toDelete {
+				ids[i] = aws.ToString(msg.MessageId)
+			}
+			assert.Equal(t, tt.wantConsumed, consumed)
+			assert.Equal(t, tt.wantDelete, ids)
+		})
+	}
+}
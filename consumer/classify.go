@@ -0,0 +1,98 @@
+package consumer
+
+import (
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"net/http"
+	"time"
+)
+
+const (
+	// ReceiveErrorFatal stops the worker and makes Start return the error.
+	ReceiveErrorFatal = ReceiveErrorClass(iota)
+	// ReceiveErrorTransient retries the receive after TransientErrorDelay.
+	ReceiveErrorTransient
+	// ReceiveErrorThrottled retries the receive with an exponential backoff starting at ThrottleBackoff.
+	ReceiveErrorThrottled
+)
+
+type ReceiveErrorClass int
+
+// ReceiveErrorClassifier decides how a worker reacts to a failed ReceiveMessage call.
+// Custom classifiers can fall back to DefaultReceiveErrorClassifier for the errors they don't handle.
+type ReceiveErrorClassifier func(err error) ReceiveErrorClass
+
+// DefaultReceiveErrorClassifier treats OverLimit, HTTP 503 and the SDK throttle error codes as throttling,
+// the errors the SDK considers retryable as transient and everything else as fatal.
+func DefaultReceiveErrorClassifier(err error) ReceiveErrorClass {
+	var overLimit *types.OverLimit
+	if errors.As(err, &overLimit) {
+		return ReceiveErrorThrottled
+	}
+
+	var respErr *awshttp.ResponseError
+	if errors.As(err, &respErr) && respErr.HTTPStatusCode() == http.StatusServiceUnavailable {
+		return ReceiveErrorThrottled
+	}
+
+	if retry.IsErrorThrottles(retry.DefaultThrottles).IsErrorThrottle(err) == aws.TrueTernary {
+		return ReceiveErrorThrottled
+	}
+
+	if retry.IsErrorRetryables(retry.DefaultRetryables).IsErrorRetryable(err) == aws.TrueTernary {
+		return ReceiveErrorTransient
+	}
+
+	return ReceiveErrorFatal
+}
+
+// receiveRetryDelay returns how long a worker waits before receiving again after err,
+// throttled being the number of consecutive throttled receives including this one.
+func (s *SQS) receiveRetryDelay(err error, throttled *int) (time.Duration, bool) {
+	classify := s.config.ReceiveErrorClassifier
+	if classify == nil {
+		classify = DefaultReceiveErrorClassifier
+	}
+
+	switch classify(err) {
+	case ReceiveErrorThrottled:
+		*throttled++
+		return s.throttleDelay(*throttled), true
+	case ReceiveErrorTransient:
+		*throttled = 0
+		return s.config.TransientErrorDelay, true
+	default:
+		return 0, false
+	}
+}
+
+// throttleDelay returns the wait after the nth consecutive throttled request, ThrottleBackoff doubled after every
+// throttled request up to MaxThrottleBackoff.
+func (s *SQS) throttleDelay(n int) time.Duration {
+	delay := s.config.ThrottleBackoff
+	for i := 1; i < n && delay < s.config.MaxThrottleBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, s.config.MaxThrottleBackoff)
+}
+
+// queueMissing counts the consecutive QueueDoesNotExist receive errors of a worker. It reports whether err is one
+// and returns SentinelErrorQueueDoesNotExist once they exceed QueueDoesNotExistThreshold.
+func (s *SQS) queueMissing(err error, missing *int) (bool, error) {
+	var notExist *types.QueueDoesNotExist
+	if !errors.As(err, &notExist) {
+		*missing = 0
+		return false, nil
+	}
+
+	*missing++
+	if *missing > s.config.QueueDoesNotExistThreshold {
+		return true, fmt.Errorf("%w: %w", SentinelErrorQueueDoesNotExist, err)
+	}
+
+	return true, nil
+}
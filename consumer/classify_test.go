@@ -0,0 +1,106 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func responseError(status int) error {
+	return &awshttp.ResponseError{
+		ResponseError: &smithyhttp.ResponseError{
+			Response: &smithyhttp.Response{Response: &http.Response{StatusCode: status}},
+			Err:      errors.New("response error"),
+		},
+	}
+}
+
+func TestDefaultReceiveErrorClassifier(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ReceiveErrorClass
+	}{
+		{name: "overLimit", err: &types.OverLimit{}, want: ReceiveErrorThrottled},
+		{name: "throttlingException", err: &smithy.GenericAPIError{Code: "ThrottlingException"}, want: ReceiveErrorThrottled},
+		{name: "serviceUnavailable", err: responseError(http.StatusServiceUnavailable), want: ReceiveErrorThrottled},
+		{name: "internalError", err: responseError(http.StatusInternalServerError), want: ReceiveErrorTransient},
+		{name: "unknown", err: errors.New("fake receive error"), want: ReceiveErrorFatal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, DefaultReceiveErrorClassifier(tt.err))
+		})
+	}
+}
+
+func TestSQS_receiveRetryDelay(t *testing.T) {
+	s := &SQS{config: &SQSConf{
+		ThrottleBackoff:     time.Second,
+		MaxThrottleBackoff:  5 * time.Second,
+		TransientErrorDelay: 100 * time.Millisecond,
+	}}
+
+	throttled := 0
+	for _, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second} {
+		delay, retry := s.receiveRetryDelay(&types.OverLimit{}, &throttled)
+		assert.True(t, retry)
+		assert.Equal(t, want, delay)
+	}
+
+	delay, retry := s.receiveRetryDelay(responseError(http.StatusBadGateway), &throttled)
+	assert.True(t, retry)
+	assert.Equal(t, 100*time.Millisecond, delay)
+	assert.Equal(t, 0, throttled)
+
+	_, retry = s.receiveRetryDelay(errors.New("fake receive error"), &throttled)
+	assert.False(t, retry)
+}
+
+func TestSQS_queueMissing(t *testing.T) {
+	s := &SQS{config: &SQSConf{QueueDoesNotExistThreshold: 2}}
+
+	missing := 0
+	for i := 0; i < 2; i++ {
+		ok, err := s.queueMissing(&types.QueueDoesNotExist{}, &missing)
+		assert.True(t, ok)
+		assert.NoError(t, err)
+	}
+
+	ok, err := s.queueMissing(&types.QueueDoesNotExist{}, &missing)
+	assert.True(t, ok)
+	assert.ErrorIs(t, err, SentinelErrorQueueDoesNotExist)
+
+	ok, err = s.queueMissing(errors.New("fake receive error"), &missing)
+	assert.False(t, ok)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, missing)
+}
+
+func TestSQS_StartQueueDoesNotExist(t *testing.T) {
+	sqsMock := new(SqsMock)
+	sqsMock.On("ReceiveMessage", mock.Anything, mock.AnythingOfType("*sqs.ReceiveMessageInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, &types.QueueDoesNotExist{})
+
+	s := &SQS{sqs: sqsMock, config: &SQSConf{
+		Queue:                      "queue",
+		Concurrency:                1,
+		QueueDoesNotExistThreshold: 2,
+		TransientErrorDelay:        time.Millisecond,
+	}}
+
+	err := s.Start(context.Background(), func(data []byte, attributes map[string]types.MessageAttributeValue) error {
+		return nil
+	})
+	assert.ErrorIs(t, err, SentinelErrorQueueDoesNotExist)
+	assert.Len(t, sqsMock.inputs, 3)
+}
@@ -0,0 +1,58 @@
+package consumer
+
+import (
+	"context"
+	"time"
+)
+
+// clock is the time source of the consumer, replaced in tests to control the time based features.
+type clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	// Sleep waits for d or until ctx is done.
+	Sleep(ctx context.Context, d time.Duration)
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (realClock) Sleep(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
+
+func (s *SQS) now() time.Time {
+	if s.clock == nil {
+		return time.Now()
+	}
+	return s.clock.Now()
+}
+
+func (s *SQS) since(t time.Time) time.Duration {
+	return s.now().Sub(t)
+}
+
+func (s *SQS) after(d time.Duration) <-chan time.Time {
+	if s.clock == nil {
+		return time.After(d)
+	}
+	return s.clock.After(d)
+}
+
+// sleep waits for d or until ctx is done.
+func (s *SQS) sleep(ctx context.Context, d time.Duration) {
+	if s.clock == nil {
+		realClock{}.Sleep(ctx, d)
+		return
+	}
+	s.clock.Sleep(ctx, d)
+}
@@ -0,0 +1,74 @@
+package consumer
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock never blocks: After fires and Sleep returns at once, moving the clock forward by the waited duration.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+func (c *fakeClock) Sleep(_ context.Context, d time.Duration) {
+	<-c.After(d)
+}
+
+func TestSQS_sleepFakeClock(t *testing.T) {
+	clk := &fakeClock{now: time.Unix(1700000000, 0)}
+	s := &SQS{config: &SQSConf{}, clock: clk}
+
+	started := s.now()
+	s.sleep(context.Background(), time.Hour)
+	assert.Equal(t, time.Hour, s.since(started))
+}
+
+func TestSQS_expiredFakeClock(t *testing.T) {
+	clk := &fakeClock{now: time.UnixMilli(1700000000000)}
+	s := &SQS{config: &SQSConf{TTLAttribute: "ttl"}, clock: clk}
+
+	msg := types.Message{
+		Attributes: map[string]string{"SentTimestamp": "1700000000000"},
+		MessageAttributes: map[string]types.MessageAttributeValue{
+			"ttl": {DataType: aws.String("String"), StringValue: aws.String("90s")},
+		},
+	}
+
+	assert.False(t, s.expired(msg))
+	s.sleep(context.Background(), 91*time.Second)
+	assert.True(t, s.expired(msg))
+	assert.Equal(t, 91*time.Second, s.message(msg).QueueLatency)
+}
+
+func TestSQS_dedupFakeClock(t *testing.T) {
+	clk := &fakeClock{now: time.Unix(1700000000, 0)}
+	s := &SQS{config: &SQSConf{DedupWindow: time.Minute}, clock: clk}
+	msg := types.Message{MessageId: aws.String("msg1")}
+
+	s.remember(context.Background(), []types.Message{msg})
+	assert.True(t, s.duplicate(context.Background(), msg))
+	s.sleep(context.Background(), time.Minute)
+	assert.False(t, s.duplicate(context.Background(), msg))
+}
@@ -2,43 +2,47 @@ package consumer
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
 	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/semaphore"
 	"log/slog"
+	"math/rand/v2"
 	"os"
 	"os/signal"
+	"sync"
 	"time"
 )
 
+// NewSQSConsumer creates the SQS client from the AWS environment variables, falling back to the default
+// credential chain (shared config, ECS container credentials, EC2 IMDS) when no static keys are set.
 func NewSQSConsumer(conf *SQSConf) (*SQS, error) {
-
-	if os.Getenv("AWS_ACCESS_KEY_ID") == "" || os.Getenv("AWS_SECRET_ACCESS_KEY") == "" || os.Getenv("AWS_REGION") == "" {
-		slog.Error("One or more AWS environment variables are not set.")
-		return nil, SentinelErrorConfigAws
-	}
-	cred := credentials.NewStaticCredentialsProvider(os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"), "")
-
-	awsCfg, err := config.LoadDefaultConfig(context.TODO(),
-		config.WithRegion(os.Getenv("AWS_REGION")),
-		config.WithCredentialsProvider(cred),
-	)
-
+	awsCfg, err := config.LoadDefaultConfig(context.TODO(), credentialsOptions(conf)...)
 	if err != nil {
 		slog.Error("Error creation AWS configuration.")
 		return nil, SentinelErrorConfigAws
 	}
-	sqsClient := sqs.NewFromConfig(awsCfg)
 
-	if conf == nil {
-		return nil, SentinelErrorConfigIsNil
+	if awsCfg.Region == "" {
+		slog.Error("AWS region is not set.")
+		return nil, SentinelErrorConfigAws
 	}
 
-	if conf.Queue == "" {
-		return nil, SentinelErrorQueueNotSet
+	return NewSQSConsumerWithAWSConfig(awsCfg, conf)
+}
+
+// NewSQSConsumerWithAWSConfig creates the SQS client from cfg instead of the AWS environment variables.
+func NewSQSConsumerWithAWSConfig(cfg aws.Config, conf *SQSConf) (*SQS, error) {
+	return newSQS(sqs.NewFromConfig(cfg, conf.clientOptions()...), conf)
+}
+
+func newSQS(sqsClient SQSClient, conf *SQSConf) (*SQS, error) {
+	if err := conf.Validate(); err != nil {
+		return nil, err
 	}
 
 	if len(conf.DeleteStrategy) == 0 {
@@ -57,82 +61,900 @@ func NewSQSConsumer(conf *SQSConf) (*SQS, error) {
 		conf.MaxNumberOfMessages = DefaultMaxNumberOfMessages
 	}
 
-	return &SQS{config: conf, sqs: sqsClient}, nil
+	if conf.ThrottleBackoff == 0 {
+		conf.ThrottleBackoff = DefaultThrottleBackoff
+	}
+
+	if conf.MaxThrottleBackoff == 0 {
+		conf.MaxThrottleBackoff = DefaultMaxThrottleBackoff
+	}
+
+	if conf.TransientErrorDelay == 0 {
+		conf.TransientErrorDelay = DefaultTransientErrorDelay
+	}
+
+	if conf.EmptyReceiveJitter == 0 {
+		conf.EmptyReceiveJitter = DefaultEmptyReceiveJitter
+	}
+
+	if conf.ClockSkewTolerance == 0 {
+		conf.ClockSkewTolerance = DefaultClockSkewTolerance
+	}
+
+	if conf.DrainTimeout == 0 {
+		conf.DrainTimeout = DefaultDrainTimeout
+	}
+
+	if conf.FIFO {
+		conf.PartitionByGroup = true
+	}
+
+	if conf.circuitBreaker() && conf.CircuitBreakerCooldown == 0 {
+		conf.CircuitBreakerCooldown = DefaultCircuitCooldown
+	}
+
+	if conf.PanicThreshold > 0 {
+		if conf.PanicWindow == 0 {
+			conf.PanicWindow = DefaultPanicWindow
+		}
+		if conf.PanicPolicy == "" {
+			conf.PanicPolicy = PanicPolicyStop
+		}
+	}
+
+	if conf.startupProbes() {
+		if conf.StartupProbeAttempts == 0 {
+			conf.StartupProbeAttempts = DefaultStartupProbeAttempts
+		}
+		if conf.StartupProbeBackoff == 0 {
+			conf.StartupProbeBackoff = DefaultStartupProbeBackoff
+		}
+	}
+
+	s := &SQS{config: conf, sqs: sqsClient}
+
+	if conf.startupProbes() {
+		if err := s.runStartupProbes(context.TODO()); err != nil {
+			return nil, err
+		}
+	}
+
+	s.checkSystemAttributes()
+
+	if conf.MaxInFlight > 0 {
+		s.inFlight = semaphore.NewWeighted(int64(conf.MaxInFlight))
+	}
+
+	return s, nil
 }
 
 func (s *SQS) Start(ctx context.Context, consumeFn ConsumerFn) error {
-	ctx, cancel := context.WithCancel(ctx)
+	return s.StartWithContext(ctx, func(_ context.Context, data []byte, attributes map[string]types.MessageAttributeValue) error {
+		return consumeFn(data, attributes)
+	})
+}
 
-	go func() {
-		c := make(chan os.Signal)
-		signal.Notify(c, os.Interrupt)
-		_ = <-c
-		cancel()
-	}()
+// StartWithContext is Start for consumer functions receiving a context cancelled when the consumer stops.
+func (s *SQS) StartWithContext(ctx context.Context, consumeFn ContextConsumerFn) error {
+	return s.start(ctx, s.consumeEach(consumeFn))
+}
+
+// StartBatch consumes every received batch with a single call to consumeFn. Under DeleteStrategyOnSuccess and
+// DeleteStrategyBatched only the messages whose MessageId is returned by consumeFn are deleted, even when it
+// also returns an error. PartitionByGroup doesn't apply to batch consumption.
+func (s *SQS) StartBatch(ctx context.Context, consumeFn BatchConsumerFn) error {
+	return s.start(ctx, s.consumeBatch(consumeFn))
+}
+
+// StartWithResult consumes the messages with a consumer function deciding what happens to every message it
+// consumed without error, see Result. Messages it fails to consume are left for redelivery. It requires a
+// DeleteStrategy deleting messages on success, DeleteStrategyImmediate deleting them before they are consumed.
+func (s *SQS) StartWithResult(ctx context.Context, consumeFn ResultConsumerFn) error {
+	if strategy := s.DeleteStrategy(); strategy != DeleteStrategyOnSuccess && strategy != DeleteStrategyBatched {
+		return fmt.Errorf("%w: StartWithResult requires DeleteStrategyOnSuccess or DeleteStrategyBatched", SentinelErrorInvalidConfig)
+	}
+	return s.start(ctx, s.consumeResults(consumeFn))
+}
+
+func (s *SQS) start(parent context.Context, handler batchHandler) error {
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+	s.stats.started.Store(s.now().UnixNano())
+	s.fatalPanic.Store(nil)
+
+	stopped := make(chan struct{})
+	defer close(stopped)
+
+	s.stopMu.Lock()
+	s.stop = cancel
+	s.stopped = stopped
+	s.stopMu.Unlock()
+
+	if s.config.AuditWriter != nil {
+		s.auditLog = newAuditLog(s.config.AuditWriter, s.logger())
+		defer s.auditLog.close()
+	}
+
+	s.logConfig()
+
+	if !s.config.DisableSignalHandling {
+		c := make(chan os.Signal, 1)
+		signal.Notify(c, s.config.shutdownSignals()...)
+		defer signal.Stop(c)
+
+		done := ctx.Done()
+		go func() {
+			select {
+			case <-c:
+				s.Stop()
+			case <-done:
+			}
+		}()
+	}
 
 	g, ctx := errgroup.WithContext(ctx)
 
+	// Stop ends the receives first, the workers returning once they consumed what they received
+	polling, stopPolling := context.WithCancel(ctx)
+	defer stopPolling()
+	s.stopMu.Lock()
+	s.stopPolling = stopPolling
+	s.stopMu.Unlock()
+
+	if s.config.PartitionByGroup && handler.partitioned {
+		partitions := newPartitions(s.config.Concurrency)
+		s.partitions = partitions
+		s.workQueue.Store(&partitions)
+		for _, p := range s.partitions {
+			g.Go(func() error {
+				return p.run(ctx)
+			})
+		}
+	}
+
+	var workers sync.WaitGroup
 	for i := 0; i < s.config.Concurrency; i++ {
+		workers.Add(1)
 		g.Go(func() error {
-			return s.handleMessages(ctx, consumeFn)
+			defer workers.Done()
+			return s.handleMessages(ctx, polling, handler)
 		})
 	}
 
-	return g.Wait()
+	// once drained, the partitions and background tasks are stopped
+	g.Go(func() error {
+		workers.Wait()
+		cancel()
+		return nil
+	})
+
+	if handler.background != nil {
+		g.Go(func() error {
+			handler.background(ctx)
+			return nil
+		})
+	}
+
+	if s.config.OldestMessageAgeInterval > 0 {
+		g.Go(func() error {
+			s.pollOldestMessageAge(ctx)
+			return nil
+		})
+	}
+
+	if s.config.QueueDepthInterval > 0 {
+		g.Go(func() error {
+			s.pollQueueDepth(ctx)
+			return nil
+		})
+	}
+
+	err := g.Wait()
+
+	// pending acknowledgements must outlive the cancelled workers
+	flushErr := s.Flush(context.WithoutCancel(parent))
+	s.reportUnprocessed()
+	s.repanic()
+
+	if s.config.LogStatsOnShutdown {
+		s.logStats()
+	}
+
+	if err != nil {
+		return err
+	}
+	return flushErr
 }
 
-func (s *SQS) handleMessages(ctx context.Context, consumeFn ConsumerFn) error {
+// Stop shuts the consumer down in two phases: the workers stop receiving messages right away and finish consuming
+// the ones they received, for up to DrainTimeout, after which the context of the messages still being consumed is
+// cancelled. Cancelling the Start context cancels them right away instead. Messages whose consumer function returns
+// an error once cancelled are not deleted.
+func (s *SQS) Stop() {
+	s.stopMu.Lock()
+	defer s.stopMu.Unlock()
+
+	if s.stop == nil {
+		return
+	}
+	if s.stopPolling == nil || s.config.DrainTimeout <= 0 {
+		s.stop()
+		return
+	}
+
+	s.stopPolling()
+	stop, stopped := s.stop, s.stopped
+	go func() {
+		select {
+		case <-s.after(s.config.DrainTimeout):
+			s.logger().Warn("drain timeout elapsed, cancelling the messages being consumed",
+				slog.Duration("drainTimeout", s.config.DrainTimeout))
+			stop()
+		case <-stopped:
+		}
+	}()
+}
+
+// cancel cancels the context of the running workers and of the messages they consume right away.
+func (s *SQS) cancel() {
+	s.stopMu.Lock()
+	defer s.stopMu.Unlock()
+
+	if s.stop != nil {
+		s.stop()
+	}
+}
+
+// Close implements io.Closer for lifecycle frameworks: it calls Stop and waits for Start to return, for up to
+// DefaultCloseTimeout. It returns nil when the consumer is not running.
+func (s *SQS) Close() error {
+	s.Stop()
+
+	s.stopMu.Lock()
+	stopped := s.stopped
+	s.stopMu.Unlock()
+
+	if stopped == nil {
+		return nil
+	}
+
+	select {
+	case <-stopped:
+		return nil
+	case <-s.after(DefaultCloseTimeout):
+		return fmt.Errorf("consumer still running %s after Close: %w", DefaultCloseTimeout, context.DeadlineExceeded)
+	}
+}
+
+// Flush deletes every acknowledgement buffered by DeleteStrategyBatched, and every throttled delete buffered by
+// DeleteRetryBufferSize, right away.
+// It is safe to call concurrently with running workers.
+func (s *SQS) Flush(ctx context.Context) error {
+	s.pending.mu.Lock()
+	msg := s.pending.messages
+	s.pending.messages = nil
+	s.pending.mu.Unlock()
+	msg = append(msg, s.takeDeleteRetries()...)
+
+	var errs []error
+	for _, chunk := range chunk(msg, maxBatchSize) {
+		if _, err := s.deleteBatch(ctx, chunk); err != nil {
+			s.addUnprocessed(chunk, nil)
+			errs = append(errs, consumerError(err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *SQS) handleMessages(ctx, polling context.Context, handler batchHandler) error {
+	w := &worker{polling: polling}
+
+	if s.config.VisibilityHeartbeat > 0 {
+		beatCtx, stopBeat := context.WithCancel(ctx)
+		defer stopBeat()
+		go s.beat(beatCtx, w)
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
 			return nil
+		case <-polling.Done():
+			return nil
 		default:
-			result, err := s.sqs.ReceiveMessage(ctx, s.pullMessagesRequest())
-
+			processed, err := s.pollCycle(ctx, handler, w)
+			if s.config.AfterPoll != nil {
+				s.config.AfterPoll(processed, err)
+			}
 			if err != nil {
 				return err
 			}
+		}
+	}
+}
+
+// pollContext returns the context of the receives of the worker, done once Stop was called.
+func (w *worker) pollContext(ctx context.Context) context.Context {
+	if w.polling == nil {
+		return ctx
+	}
+	return w.polling
+}
+
+// pollCycle receives and consumes one batch of messages, unless the circuit breaker is open,
+// and returns the number of messages handed to the consumer function.
+func (s *SQS) pollCycle(ctx context.Context, handler batchHandler, w *worker) (int, error) {
+	polling := w.pollContext(ctx)
+
+	if s.config.circuitBreaker() {
+		if wait := s.breaker.allow(s.now(), s.config.CircuitBreakerCooldown); wait > 0 {
+			s.sleep(polling, wait)
+			return 0, nil
+		}
+		defer s.breaker.release()
+	}
+
+	if err := s.retryDeletes(ctx); err != nil {
+		if err := s.deleteFailed(err); err != nil {
+			return 0, err
+		}
+	}
+
+	w.strategy = s.DeleteStrategy()
+	s.workerStarted(w)
+	if !s.pace(polling, w) {
+		return 0, nil
+	}
+
+	input := s.pullMessagesRequest()
+	input.WaitTimeSeconds = s.waitTime(w)
+	input.ReceiveRequestAttemptId = s.receiveAttempt(w)
+	result, err := s.sqs.ReceiveMessage(polling, input)
+	w.received = s.now()
+	w.attemptFailed = err != nil
+
+	// Stop aborts the long poll in flight
+	if err != nil && polling.Err() != nil {
+		return 0, nil
+	}
+
+	if err != nil {
+		if missing, err := s.queueMissing(err, &w.missing); missing {
+			if err != nil {
+				return 0, err
+			}
+			s.logger().Warn("queue does not exist, retrying", slog.Int("attempt", w.missing))
+			s.sleep(polling, s.config.TransientErrorDelay)
+			return 0, nil
+		}
+
+		delay, retry := s.receiveRetryDelay(err, &w.throttled)
+		if !retry {
+			return 0, consumerError(err)
+		}
+		s.logger().Warn("error receiving messages, retrying", slog.Any("error", err.Error()), slog.Duration("delay", delay),
+			slog.String("receiveRequestAttemptId", aws.ToString(input.ReceiveRequestAttemptId)), requestIDAttr(err))
+		s.sleep(polling, delay)
+		return 0, nil
+	}
+	w.throttled = 0
+	w.missing = 0
+
+	s.observeReceive(w, len(result.Messages) == 0)
+	s.observeDelivery(ctx, int(input.MaxNumberOfMessages), len(result.Messages))
+	s.adaptWaitTime(w, len(result.Messages) == 0)
+	if len(result.Messages) == 0 {
+		s.sleep(polling, s.emptyReceiveDelay())
+		return 0, nil
+	}
+	s.stats.received.Add(int64(len(result.Messages)))
+	s.metrics().MessagesReceived(s.queueName(), len(result.Messages))
+	s.checkFIFOAttributes(result.Messages)
+	s.checkClockSkew(result.Messages)
+
+	if s.config.TotalShards > 1 {
+		if result.Messages = s.shard(ctx, result.Messages); len(result.Messages) == 0 {
+			s.sleep(polling, s.emptyReceiveDelay())
+			return 0, nil
+		}
+	}
+
+	if s.config.InitialVisibilityExtension > 0 && s.strategy(w) != DeleteStrategyImmediate {
+		s.extendVisibility(ctx, result.Messages, s.config.InitialVisibilityExtension)
+	}
+
+	if s.strategy(w) == DeleteStrategyImmediate {
+		if err := s.deleteSqsMessages(ctx, result.Messages); err != nil {
+			if err := s.deleteFailed(err); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	if s.heartbeat(w) {
+		w.inProgress.add(result.Messages)
+		defer w.inProgress.clear()
+	}
 
-			if len(result.Messages) == 0 {
-				time.Sleep(1 * time.Second)
+	tracked := s.trackUnprocessed(w, result.Messages)
+	toDelete, consumed := s.consumeMessages(ctx, w, result.Messages, handler)
+
+	// the consumed messages are deleted even when the consumer is stopping
+	ackErr := s.ackMessages(context.WithoutCancel(ctx), w, toDelete)
+	if ctx.Err() != nil {
+		if ackErr != nil {
+			toDelete = nil
+		}
+		s.addUnprocessed(tracked, toDelete)
+	}
+	if ackErr != nil {
+		if err := s.deleteFailed(ackErr); err != nil {
+			return consumed, err
+		}
+	}
+
+	if w.panicStorm() {
+		return consumed, SentinelErrorPanicStorm
+	}
+
+	// a batch made only of expired or filtered out messages is handled like an empty receive
+	if consumed == 0 {
+		s.sleep(polling, s.emptyReceiveDelay())
+	}
+
+	return consumed, nil
+}
+
+// consumeMessages hands the messages of the batch that are neither expired nor filtered out to consume, the ones
+// declined by ProcessDecider to the declined handler, and returns the messages to delete under the configured
+// strategy, along with the number of messages handed to them.
+func (s *SQS) consumeMessages(ctx context.Context, w *worker, messages []types.Message, handler batchHandler) ([]types.Message, int) {
+	toDelete := make([]types.Message, 0)
+	consumable := make([]types.Message, 0, len(messages))
+	var declined, saturated []types.Message
+	var reserved []string
+	defer func() {
+		s.groupInFlight.release(reserved)
+	}()
+
+	drop := func(msg types.Message) {
+		if s.strategy(w) != DeleteStrategyImmediate {
+			toDelete = append(toDelete, s.releaseMessage(msg))
+		}
+	}
+
+	for i, msg := range messages {
+		if s.config.StreamBatches {
+			messages[i] = types.Message{}
+		}
+
+		if s.expired(msg) {
+			s.stats.expired.Add(1)
+			drop(msg)
+			continue
+		}
+
+		if s.config.ExtendedClient {
+			if err := s.resolveExtendedPayload(ctx, &msg); err != nil {
+				s.failed(1, err)
 				continue
 			}
+		}
 
-			if s.config.DeleteStrategy == DeleteStrategyImmediate {
-				if err := s.deleteSqsMessages(ctx, result.Messages); err != nil {
-					return err
+		if s.config.DecompressBodies && s.gzipped(msg) {
+			if err := s.decompress(&msg); err != nil {
+				if s.skipUndecompressable(ctx, msg, err) {
+					drop(msg)
 				}
+				continue
 			}
+		}
 
-			toDelete := make([]types.Message, 0)
-			for _, msg := range result.Messages {
-				if err := consumeFn([]byte(*msg.Body), msg.MessageAttributes); err != nil {
-					slog.Error("error in consume function", slog.Any("error", err.Error()))
-					continue
+		if s.config.BodyCharset != nil {
+			if err := s.transcode(&msg); err != nil {
+				if s.skipUntranscodable(ctx, msg, err) {
+					drop(msg)
 				}
+				continue
+			}
+		}
+
+		if s.config.UnwrapEventBridge {
+			unwrapEventBridge(&msg)
+		}
+
+		if s.stale(msg) {
+			s.stats.stale.Add(1)
+			if s.skipStale(ctx, msg) {
+				drop(msg)
+			}
+			continue
+		}
+
+		if s.oversized(msg) {
+			s.stats.oversized.Add(1)
+			if s.skipOversized(ctx, msg) {
+				drop(msg)
+			}
+			continue
+		}
+
+		if s.config.Filter != nil && !s.config.Filter(s.message(msg)) {
+			s.stats.filtered.Add(1)
+			drop(msg)
+			continue
+		}
+
+		if s.duplicate(ctx, msg) {
+			s.stats.duplicates.Add(1)
+			drop(msg)
+			continue
+		}
+
+		if m := s.message(msg); !m.SentTimestamp.IsZero() {
+			s.metrics().QueueLatency(s.queueName(), m.QueueLatency)
+		}
+
+		if s.config.ProcessDecider != nil && !s.config.ProcessDecider(s.message(msg)) {
+			s.stats.declined.Add(1)
+			declined = append(declined, msg)
+			continue
+		}
+
+		if s.config.MaxInFlightPerGroup > 0 && s.strategy(w) != DeleteStrategyImmediate {
+			key := s.groupKey(msg)
+			if !s.groupInFlight.reserve(key, s.config.MaxInFlightPerGroup) {
+				s.stats.groupSaturated.Add(1)
+				saturated = append(saturated, msg)
+				continue
+			}
+			reserved = append(reserved, key)
+		}
+
+		consumable = append(consumable, msg)
+	}
+
+	if len(saturated) > 0 {
+		s.extendVisibility(ctx, saturated, 0)
+	}
+
+	routed := 0
+	if len(declined) > 0 {
+		if handler.declined != nil {
+			routed = len(declined)
+			toDelete = append(toDelete, s.acknowledged(w, handler.declined(ctx, w, declined))...)
+		} else if s.strategy(w) != DeleteStrategyImmediate {
+			s.extendVisibility(ctx, declined, 0)
+		}
+	}
+
+	if len(consumable) == 0 {
+		return toDelete, routed
+	}
+
+	if s.config.BatchSorter != nil {
+		consumable = s.sortBatch(consumable)
+	}
+
+	toDelete = append(toDelete, s.acknowledged(w, handler.consume(ctx, w, consumable))...)
+
+	return toDelete, len(consumable) + routed
+}
+
+// acknowledged returns the consumed messages to delete under the strategy of the poll cycle.
+func (s *SQS) acknowledged(w *worker, consumed []types.Message) []types.Message {
+	if strategy := s.strategy(w); strategy == DeleteStrategyOnSuccess || strategy == DeleteStrategyBatched {
+		return consumed
+	}
+	return nil
+}
+
+// sortBatch orders the messages of a receive with BatchSorter.
+func (s *SQS) sortBatch(msgs []types.Message) []types.Message {
+	batch := make([]Message, len(msgs))
+	for i, msg := range msgs {
+		batch[i] = s.message(msg)
+	}
+
+	sorted := s.config.BatchSorter(batch)
+	msgs = make([]types.Message, len(sorted))
+	for i, msg := range sorted {
+		msgs[i] = msg.Message
+	}
+	return msgs
+}
+
+// consumeEach consumes messages one by one, on their group partition when PartitionByGroup is enabled.
+func (s *SQS) consumeEach(consumeFn ContextConsumerFn) batchHandler {
+	if s.config.SplitJSONArray {
+		consumeFn = splitJSONArray(consumeFn)
+	}
+
+	handler := s.eachMessage(func(ctx context.Context, w *worker, msg types.Message) bool {
+		return s.consumeOne(ctx, w, msg, func(msgCtx context.Context) error {
+			return consumeFn(msgCtx, []byte(*msg.Body), msg.MessageAttributes)
+		})
+	})
+
+	if declinedFn := s.config.DeclinedConsumer; declinedFn != nil {
+		if s.config.SplitJSONArray {
+			declinedFn = splitJSONArray(declinedFn)
+		}
+		handler.declined = s.eachMessage(func(ctx context.Context, w *worker, msg types.Message) bool {
+			return s.consumeOne(ctx, w, msg, func(msgCtx context.Context) error {
+				return declinedFn(msgCtx, []byte(*msg.Body), msg.MessageAttributes)
+			})
+		}).consume
+	}
+	return handler
+}
 
-				if s.config.DeleteStrategy == DeleteStrategyOnSuccess {
-					toDelete = append(toDelete, msg)
+// eachMessage runs consume for every message, on their group partition when PartitionByGroup is enabled,
+// and returns the messages it returned true for.
+func (s *SQS) eachMessage(consume func(ctx context.Context, w *worker, msg types.Message) bool) batchHandler {
+	return batchHandler{
+		partitioned: true,
+		consume: func(ctx context.Context, w *worker, msgs []types.Message) []types.Message {
+			consumeAndRelease := func(msg types.Message) *types.Message {
+				if !consume(ctx, w, msg) {
+					return nil
 				}
+				s.remember(context.WithoutCancel(ctx), []types.Message{msg})
+				released := s.releaseMessage(msg)
+				return &released
 			}
 
-			if err := s.deleteSqsMessages(ctx, toDelete); err != nil {
-				return err
+			if s.partitions != nil {
+				defer s.recordWorkQueueDepth()
+				return s.partitions.consume(ctx, msgs, s.groupKey, consumeAndRelease, s.waitStarted)
+			}
+
+			if s.config.ConsumeConcurrently {
+				return s.consumeConcurrently(msgs, consumeAndRelease)
+			}
+
+			consumed := make([]types.Message, 0, len(msgs))
+			for i, msg := range msgs {
+				if s.config.StreamBatches {
+					msgs[i] = types.Message{}
+				}
+				if released := consumeAndRelease(msg); released != nil {
+					consumed = append(consumed, *released)
+				}
 			}
+			return consumed
+		},
+	}
+}
+
+// consumeConcurrently consumes every message on its own goroutine, at most MaxInFlight at a time, and returns the
+// non nil results of consumeFn in receive order.
+func (s *SQS) consumeConcurrently(msgs []types.Message, consumeFn func(types.Message) *types.Message) []types.Message {
+	var g errgroup.Group
+	if s.config.MaxInFlight > 0 {
+		g.SetLimit(s.config.MaxInFlight)
+	}
+
+	results := make([]*types.Message, len(msgs))
+	for i, msg := range msgs {
+		if s.config.StreamBatches {
+			msgs[i] = types.Message{}
+		}
+		g.Go(func() error {
+			results[i] = consumeFn(msg)
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	consumed := make([]types.Message, 0, len(msgs))
+	for _, msg := range results {
+		if msg != nil {
+			consumed = append(consumed, *msg)
+		}
+	}
+	return consumed
+}
+
+// releaseMessage drops the references to the body and attributes of a consumed message with StreamBatches,
+// keeping what its deletion requires.
+func (s *SQS) releaseMessage(msg types.Message) types.Message {
+	if !s.config.StreamBatches {
+		return msg
+	}
+	return types.Message{MessageId: msg.MessageId, ReceiptHandle: msg.ReceiptHandle}
+}
+
+// consumeOne calls fn with the message context and reports whether it succeeded.
+func (s *SQS) consumeOne(ctx context.Context, w *worker, msg types.Message, fn func(msgCtx context.Context) error) bool {
+	if s.heartbeat(w) {
+		defer w.inProgress.remove(msg)
+	}
+
+	if !s.paceGroup(ctx, msg) {
+		return false
+	}
+
+	if !s.acquire(ctx, 1) {
+		return false
+	}
+	defer s.release(1)
+
+	msgCtx, cancel := s.visibilityDeadline(ctx, w)
+	defer cancel()
+
+	m := s.message(msg)
+	msgCtx = context.WithValue(msgCtx, messageKey{}, m)
+	if deadline, ok := s.retryDeadline(w); ok {
+		msgCtx = context.WithValue(msgCtx, retryDeadlineKey{}, deadline)
+	}
+	if s.config.FIFO {
+		msgCtx = context.WithValue(msgCtx, receiveAttemptKey{}, w.attemptID)
+	}
+	if s.config.RetryBudgetRate > 0 {
+		msgCtx = context.WithValue(msgCtx, retryBudgetKey{}, s.takeRetryToken)
+	}
+	if s.config.HandlerDeps != nil {
+		msgCtx = context.WithValue(msgCtx, depsKey{}, s.config.HandlerDeps)
+	}
+	msgCtx, traced := s.shouldTrace(msgCtx, m)
+
+	started := s.now()
+	err := s.protect(w, func() error {
+		return fn(msgCtx)
+	})
+	elapsed := s.since(started)
+	s.checkSlow(elapsed, slog.String("messageId", aws.ToString(msg.MessageId)))
+	s.observeConsumption(elapsed, 1)
+
+	if traced {
+		s.traceConsumed(msgCtx, m, elapsed, err)
+	}
+
+	if err != nil && msgCtx.Err() != nil {
+		s.audit(msg, elapsed, AuditOutcomeCancelled, err)
+		s.messageLogger().Info("consume function cancelled, the message will be redelivered",
+			slog.String("messageId", aws.ToString(msg.MessageId)),
+			slog.Any("error", err.Error()))
+		return false
+	}
 
+	if kind := s.classifyError(err); err != nil && kind != ErrorIgnore {
+		s.audit(msg, elapsed, AuditOutcomeFailure, err)
+		s.metrics().MessagesProcessed(s.queueName(), 0, 1, elapsed)
+		s.failed(1, err)
+		if kind == ErrorTerminal {
+			return s.terminalFailed(ctx, msg, err)
 		}
+		if s.strategy(w) != DeleteStrategyImmediate {
+			s.retryLater(ctx, msg, err)
+		}
+		return false
+	}
+
+	s.audit(msg, elapsed, AuditOutcomeSuccess, nil)
+	s.metrics().MessagesProcessed(s.queueName(), 1, 0, elapsed)
+	s.messageLogger().Debug("message consumed",
+		slog.String("messageId", aws.ToString(msg.MessageId)),
+		slog.Duration("duration", elapsed))
+
+	s.succeeded(1)
+	return true
+}
+
+func (s *SQS) consumeBatch(consumeFn BatchConsumerFn) batchHandler {
+	return batchHandler{
+		consume: func(ctx context.Context, w *worker, msgs []types.Message) []types.Message {
+			n := len(msgs)
+			if s.config.MaxInFlight > 0 {
+				n = min(n, s.config.MaxInFlight)
+			}
+			if !s.acquire(ctx, n) {
+				return nil
+			}
+			defer s.release(n)
+
+			batch := make([]Message, len(msgs))
+			for i, msg := range msgs {
+				batch[i] = s.message(msg)
+			}
+
+			var ids []string
+			started := s.now()
+			err := s.protect(w, func() (err error) {
+				ids, err = consumeFn(batch)
+				return err
+			})
+			elapsed := s.since(started)
+			s.checkSlow(elapsed, slog.Int("messages", len(batch)))
+			s.observeConsumption(elapsed, len(batch))
+
+			acked := make(map[string]bool, len(ids))
+			for _, id := range ids {
+				acked[id] = true
+			}
+
+			consumed := make([]types.Message, 0, len(ids))
+			for _, msg := range msgs {
+				if acked[aws.ToString(msg.MessageId)] {
+					consumed = append(consumed, msg)
+					s.audit(msg, elapsed, AuditOutcomeSuccess, nil)
+				} else {
+					s.audit(msg, elapsed, AuditOutcomeFailure, err)
+				}
+			}
+
+			s.metrics().MessagesProcessed(s.queueName(), len(consumed), len(msgs)-len(consumed), elapsed)
+			s.remember(context.WithoutCancel(ctx), consumed)
+
+			if failed := len(msgs) - len(consumed); failed > 0 || err != nil {
+				s.stats.processed.Add(int64(len(consumed)))
+				s.failed(failed, err)
+			} else {
+				s.succeeded(len(consumed))
+			}
+
+			return consumed
+		},
 	}
 }
 
+// checkSlow logs consumptions that took longer than SlowHandlerThreshold.
+func (s *SQS) checkSlow(elapsed time.Duration, attrs ...any) {
+	if s.config.SlowHandlerThreshold > 0 && elapsed > s.config.SlowHandlerThreshold {
+		s.logger().Warn("slow consume function", append(attrs, slog.Duration("duration", elapsed))...)
+	}
+}
+
+func (s *SQS) acquire(ctx context.Context, n int) bool {
+	if s.inFlight == nil || s.inFlight.TryAcquire(int64(n)) {
+		return true
+	}
+
+	defer s.waitStarted()()
+	return s.inFlight.Acquire(ctx, int64(n)) == nil
+}
+
+func (s *SQS) release(n int) {
+	if s.inFlight != nil {
+		s.inFlight.Release(int64(n))
+	}
+}
+
+func (s *SQS) succeeded(n int) {
+	s.stats.processed.Add(int64(n))
+	if s.config.circuitBreaker() {
+		s.breaker.success()
+	}
+}
+
+func (s *SQS) failed(n int, err error) {
+	s.stats.failed.Add(int64(n))
+	if s.config.circuitBreaker() {
+		s.breaker.failure(s.now(), s.config.CircuitBreakerThreshold)
+	}
+	if err != nil {
+		s.logger().Error("error in consume function", slog.Any("error", err.Error()))
+	}
+}
+
+func (s *SQS) ackMessages(ctx context.Context, w *worker, msg []types.Message) error {
+	if s.strategy(w) == DeleteStrategyBatched {
+		return s.bufferDeletes(ctx, msg)
+	}
+	return s.deleteSqsMessages(ctx, msg)
+}
+
 func (s *SQS) pullMessagesRequest() *sqs.ReceiveMessageInput {
 
 	r := &sqs.ReceiveMessageInput{
-		MessageSystemAttributeNames: []types.MessageSystemAttributeName{types.MessageSystemAttributeNameAll},
+		MessageSystemAttributeNames: s.systemAttributeNames(),
 		MessageAttributeNames: []string{
 			"All",
 		},
 		QueueUrl:            aws.String(s.config.Queue),
-		MaxNumberOfMessages: s.config.MaxNumberOfMessages,
+		MaxNumberOfMessages: s.receiveBatchSize(),
 		VisibilityTimeout:   s.config.VisibilityTimeout,
 		WaitTimeSeconds:     s.config.WaitTimeSeconds,
 	}
@@ -140,34 +962,166 @@ func (s *SQS) pullMessagesRequest() *sqs.ReceiveMessageInput {
 }
 
 func (s *SQS) deleteSqsMessages(ctx context.Context, msg []types.Message) error {
+	msg = s.deletable(msg)
 	if len(msg) == 0 {
 		return nil
 	}
 
-	chunks := chunk(msg, 10) // max batch size for SQS is 10
+	for _, chunk := range chunk(msg, maxBatchSize) {
+		if _, err := s.deleteBatch(ctx, chunk); err != nil {
+			if s.throttledDelete(err) {
+				s.logger().Warn("delete throttled, retrying later", slog.Int("messages", len(chunk)))
+				s.deferDeletes(chunk)
+				continue
+			}
+			return err
+		}
+	}
+
+	return nil
 
-	for _, chunk := range chunks {
-		batch := make([]types.DeleteMessageBatchRequestEntry, len(chunk))
+}
 
-		for i, v := range chunk {
-			batch[i] = types.DeleteMessageBatchRequestEntry{
-				Id:            aws.String(*v.MessageId),
-				ReceiptHandle: v.ReceiptHandle,
-			}
+// deleteFailed returns the delete error that stops the worker, or nil when ContinueOnDeleteError lets the
+// messages be redelivered instead.
+func (s *SQS) deleteFailed(err error) error {
+	if !s.config.ContinueOnDeleteError {
+		return consumerError(err)
+	}
+
+	s.logger().Error("error deleting messages, they will be redelivered", slog.Any("error", err.Error()), requestIDAttr(err))
+	return nil
+}
+
+// deletable returns the messages having both a MessageId and a ReceiptHandle, logging the others: SQS can't
+// delete them and a batch holding one would be rejected as a whole.
+func (s *SQS) deletable(msg []types.Message) []types.Message {
+	valid := make([]types.Message, 0, len(msg))
+	for _, v := range msg {
+		if v.MessageId == nil || v.ReceiptHandle == nil {
+			s.logger().Warn("skipping delete of message missing its MessageId or ReceiptHandle",
+				slog.String("messageId", aws.ToString(v.MessageId)))
+			continue
+		}
+		valid = append(valid, v)
+	}
+	return valid
+}
+
+// deleteBatch deletes msg, at most maxBatchSize messages, and returns the entries SQS failed to delete.
+func (s *SQS) deleteBatch(ctx context.Context, msg []types.Message) ([]types.BatchResultErrorEntry, error) {
+	batch := make([]types.DeleteMessageBatchRequestEntry, len(msg))
+
+	for i, v := range msg {
+		batch[i] = types.DeleteMessageBatchRequestEntry{
+			Id:            v.MessageId,
+			ReceiptHandle: v.ReceiptHandle,
 		}
+	}
 
-		_, err := s.sqs.DeleteMessageBatch(ctx, &sqs.DeleteMessageBatchInput{
-			Entries:  batch,
-			QueueUrl: aws.String(s.config.Queue),
-		})
+	out, err := s.sqs.DeleteMessageBatch(ctx, &sqs.DeleteMessageBatchInput{
+		Entries:  batch,
+		QueueUrl: aws.String(s.config.Queue),
+	})
 
-		if err != nil {
-			return err
+	if err != nil {
+		s.stats.deleteFailed.Add(int64(len(msg)))
+		return nil, err
+	}
+
+	s.stats.deleted.Add(int64(len(out.Successful)))
+	s.stats.deleteFailed.Add(int64(len(out.Failed)))
+	s.metrics().MessagesDeleted(s.queueName(), len(out.Successful))
+
+	for _, failed := range out.Failed {
+		if aws.ToString(failed.Code) == receiptHandleIsInvalid {
+			s.logger().Warn("receipt handle expired before the message was deleted, it has been or will be redelivered: "+
+				"raise VisibilityTimeout above the consumption time",
+				slog.String("messageId", aws.ToString(failed.Id)))
+			continue
 		}
+		s.logger().Error("error deleting message",
+			slog.String("messageId", aws.ToString(failed.Id)),
+			slog.String("code", aws.ToString(failed.Code)),
+			slog.String("error", aws.ToString(failed.Message)))
 	}
 
-	return nil
+	if s.config.OnDeleteError != nil && len(out.Failed) > 0 {
+		s.config.OnDeleteError(out.Failed)
+	}
 
+	if s.config.OnDelete != nil && len(out.Successful) > 0 {
+		ids := make([]string, len(out.Successful))
+		for i, entry := range out.Successful {
+			ids[i] = aws.ToString(entry.Id)
+		}
+		s.config.OnDelete(ids)
+	}
+
+	return out.Failed, nil
+}
+
+// bufferDeletes queues msg for deletion and sends every full batch that is pending.
+func (s *SQS) bufferDeletes(ctx context.Context, msg []types.Message) error {
+	s.pending.mu.Lock()
+	s.pending.messages = append(s.pending.messages, msg...)
+	full := len(s.pending.messages) / maxBatchSize * maxBatchSize
+	ready := s.pending.messages[:full:full]
+	s.pending.messages = s.pending.messages[full:]
+	s.pending.mu.Unlock()
+
+	return s.deleteSqsMessages(ctx, ready)
+}
+
+// pace waits for MinPollInterval to elapse since the previous receive of the worker and reports whether the
+// worker can receive, false when ctx is done while waiting.
+func (s *SQS) pace(ctx context.Context, w *worker) bool {
+	if s.config.MinPollInterval <= 0 {
+		return true
+	}
+
+	if !w.polled.IsZero() {
+		if wait := s.config.MinPollInterval - s.since(w.polled); wait > 0 {
+			s.sleep(ctx, wait)
+			if ctx.Err() != nil {
+				return false
+			}
+		}
+	}
+	w.polled = s.now()
+	return true
+}
+
+// emptyReceiveDelay is the wait after a receive without consumable messages, spread by EmptyReceiveJitter so that
+// the workers of a fleet don't poll in lockstep.
+func (s *SQS) emptyReceiveDelay() time.Duration {
+	if s.config.EmptyReceiveJitter <= 0 {
+		return emptyReceiveDelay
+	}
+	return emptyReceiveDelay + rand.N(s.config.EmptyReceiveJitter)
+}
+
+// logger returns the configured logger with the consumer name attribute.
+func (s *SQS) logger() *slog.Logger {
+	s.namedLogger.once.Do(func() {
+		logger := s.config.Logger
+		if logger == nil {
+			logger = slog.Default()
+		}
+		if name := s.name(); name != "" {
+			logger = logger.With(slog.String("consumer", name))
+		}
+		s.namedLogger.logger = logger
+	})
+	return s.namedLogger.logger
+}
+
+// name returns Name, defaulting to the queue name.
+func (s *SQS) name() string {
+	if s.config.Name != "" {
+		return s.config.Name
+	}
+	return s.queueName()
 }
 
 func chunk(rows []types.Message, chunkSize int) [][]types.Message {
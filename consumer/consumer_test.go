@@ -1,6 +1,7 @@
 package consumer
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -13,29 +14,95 @@ import (
 	_ "github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
 	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"testing"
 	"time"
 )
 
 type SqsMock struct {
 	mock.Mock
-	inputs       []*sqs.ReceiveMessageInput
-	receiveError error
-	deleteInputs []*sqs.DeleteMessageBatchInput
-	deleteError  error
+	// mu guards the recorded inputs, appended to by the workers of every consumer sharing the mock
+	mu                    sync.Mutex
+	inputs                []*sqs.ReceiveMessageInput
+	receiveError          error
+	deleteInputs          []*sqs.DeleteMessageBatchInput
+	deleteError           error
+	sendInputs            []*sqs.SendMessageInput
+	visibilityInputs      []*sqs.ChangeMessageVisibilityInput
+	visibilityBatchInputs []*sqs.ChangeMessageVisibilityBatchInput
+	queueAttributes       map[string]string
+	failDeleteID          string
+	failDeleteCode        string
 }
 
 func (m *SqsMock) ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
 	args := m.Called(ctx, params, optFns)
+	m.mu.Lock()
 	m.inputs = append(m.inputs, params)
+	m.mu.Unlock()
 	return getQueueContent(), args.Error(1)
 }
 
 func (m *SqsMock) DeleteMessageBatch(ctx context.Context, params *sqs.DeleteMessageBatchInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageBatchOutput, error) {
 	args := m.Called(ctx, params, optFns)
+	m.mu.Lock()
 	m.deleteInputs = append(m.deleteInputs, params)
-	return nil, args.Error(1)
+	m.mu.Unlock()
+
+	out := &sqs.DeleteMessageBatchOutput{}
+	for _, entry := range params.Entries {
+		if *entry.Id == m.failDeleteID {
+			code := m.failDeleteCode
+			if code == "" {
+				code = "InternalError"
+			}
+			out.Failed = append(out.Failed, types.BatchResultErrorEntry{Id: entry.Id, Code: aws.String(code)})
+			continue
+		}
+		out.Successful = append(out.Successful, types.DeleteMessageBatchResultEntry{Id: entry.Id})
+	}
+	return out, args.Error(1)
+}
+
+func (m *SqsMock) ChangeMessageVisibility(ctx context.Context, params *sqs.ChangeMessageVisibilityInput, optFns ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error) {
+	args := m.Called(ctx, params, optFns)
+	m.mu.Lock()
+	m.visibilityInputs = append(m.visibilityInputs, params)
+	m.mu.Unlock()
+	return &sqs.ChangeMessageVisibilityOutput{}, args.Error(1)
+}
+
+func (m *SqsMock) ChangeMessageVisibilityBatch(ctx context.Context, params *sqs.ChangeMessageVisibilityBatchInput, optFns ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityBatchOutput, error) {
+	args := m.Called(ctx, params, optFns)
+	m.mu.Lock()
+	m.visibilityBatchInputs = append(m.visibilityBatchInputs, params)
+	m.mu.Unlock()
+	return &sqs.ChangeMessageVisibilityBatchOutput{}, args.Error(1)
+}
+
+func (m *SqsMock) GetQueueUrl(ctx context.Context, params *sqs.GetQueueUrlInput, optFns ...func(*sqs.Options)) (*sqs.GetQueueUrlOutput, error) {
+	args := m.Called(ctx, params, optFns)
+	return &sqs.GetQueueUrlOutput{QueueUrl: aws.String("https://sqs.eu-west-1.amazonaws.com/123456789012/" + *params.QueueName)}, args.Error(1)
+}
+
+func (m *SqsMock) GetQueueAttributes(ctx context.Context, params *sqs.GetQueueAttributesInput, optFns ...func(*sqs.Options)) (*sqs.GetQueueAttributesOutput, error) {
+	args := m.Called(ctx, params, optFns)
+	return &sqs.GetQueueAttributesOutput{Attributes: m.queueAttributes}, args.Error(1)
+}
+
+func (m *SqsMock) SendMessage(ctx context.Context, params *sqs.SendMessageInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error) {
+	args := m.Called(ctx, params, optFns)
+	m.mu.Lock()
+	m.sendInputs = append(m.sendInputs, params)
+	m.mu.Unlock()
+	return &sqs.SendMessageOutput{MessageId: aws.String("sent")}, args.Error(1)
 }
 
 func setEnv(keyValue ...string) {
@@ -113,6 +180,12 @@ func TestNewSQSWorker(t *testing.T) {
 					MaxNumberOfMessages: DefaultMaxNumberOfMessages,
 					WaitTimeSeconds:     DefaultWaitTimeSeconds,
 					DeleteStrategy:      DeleteStrategyImmediate,
+					ThrottleBackoff:     DefaultThrottleBackoff,
+					MaxThrottleBackoff:  DefaultMaxThrottleBackoff,
+					TransientErrorDelay: DefaultTransientErrorDelay,
+					EmptyReceiveJitter:  DefaultEmptyReceiveJitter,
+					ClockSkewTolerance:  DefaultClockSkewTolerance,
+					DrainTimeout:        DefaultDrainTimeout,
 				},
 				sqs: svc,
 			},
@@ -187,6 +260,84 @@ func TestNewSQSWorker(t *testing.T) {
 	}
 }
 
+func TestNewSQSConsumerWithAWSConfig(t *testing.T) {
+	unsetEnv("AWS_REGION", "baz", "AWS_SECRET_ACCESS_KEY", "foo", "AWS_ACCESS_KEY_ID", "bar")
+
+	cfg := aws.Config{
+		Region:      "baz",
+		Credentials: credentials.NewStaticCredentialsProvider("bar", "foo", ""),
+	}
+
+	got, err := NewSQSConsumerWithAWSConfig(cfg, &SQSConf{Queue: "queue"})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultConcurrency, got.config.Concurrency)
+	assert.Equal(t, DeleteStrategyImmediate, got.config.DeleteStrategy)
+
+	_, err = NewSQSConsumerWithAWSConfig(cfg, nil)
+	require.ErrorIs(t, err, SentinelErrorConfigIsNil)
+}
+
+func TestSQS_deleteExpiredReceiptHandle(t *testing.T) {
+	sqsMock := &SqsMock{failDeleteID: "msg2", failDeleteCode: receiptHandleIsInvalid}
+	sqsMock.On("DeleteMessageBatch", mock.Anything, mock.AnythingOfType("*sqs.DeleteMessageBatchInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+
+	logs := &bytes.Buffer{}
+	s := &SQS{config: &SQSConf{Queue: "queue", Logger: slog.New(slog.NewTextHandler(logs, nil))}, sqs: sqsMock}
+
+	require.NoError(t, s.deleteSqsMessages(context.Background(), getQueueContent().Messages))
+	assert.Contains(t, logs.String(), "level=WARN")
+	assert.Contains(t, logs.String(), "messageId=msg2")
+	assert.Contains(t, logs.String(), "VisibilityTimeout")
+}
+
+func TestSQS_logger(t *testing.T) {
+	tests := []struct {
+		name string
+		conf SQSConf
+		want string
+	}{
+		{name: "shouldDefaultToQueueName", conf: SQSConf{Queue: "https://sqs.eu-west-1.amazonaws.com/123456789012/orders"}, want: "consumer=orders"},
+		{name: "shouldUseName", conf: SQSConf{Queue: "https://sqs.eu-west-1.amazonaws.com/123456789012/orders", Name: "billing"}, want: "consumer=billing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := &bytes.Buffer{}
+			tt.conf.Logger = slog.New(slog.NewTextHandler(logs, nil))
+			s := &SQS{config: &tt.conf}
+
+			s.logger().Info("first")
+			s.messageLogger().Warn("second")
+			assert.Equal(t, 2, strings.Count(logs.String(), tt.want))
+		})
+	}
+}
+
+func TestSQS_deleteMissingIdentifiers(t *testing.T) {
+	sqsMock := new(SqsMock)
+	sqsMock.On("DeleteMessageBatch", mock.Anything, mock.AnythingOfType("*sqs.DeleteMessageBatchInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+
+	logs := &bytes.Buffer{}
+	s := &SQS{config: &SQSConf{Queue: "queue", Logger: slog.New(slog.NewTextHandler(logs, nil))}, sqs: sqsMock}
+
+	messages := []types.Message{
+		{ReceiptHandle: aws.String("handle1")},
+		{MessageId: aws.String("msg2"), ReceiptHandle: aws.String("handle2")},
+		{MessageId: aws.String("msg3")},
+	}
+	require.NoError(t, s.deleteSqsMessages(context.Background(), messages))
+
+	require.Len(t, sqsMock.deleteInputs, 1)
+	require.Len(t, sqsMock.deleteInputs[0].Entries, 1)
+	assert.Equal(t, "msg2", aws.ToString(sqsMock.deleteInputs[0].Entries[0].Id))
+	assert.Equal(t, 2, strings.Count(logs.String(), "missing its MessageId or ReceiptHandle"))
+
+	require.NoError(t, s.deleteSqsMessages(context.Background(), messages[:1]))
+	assert.Len(t, sqsMock.deleteInputs, 1)
+}
+
 var consumeTestFunc ConsumerFn
 
 func TestSQS_Start(t *testing.T) {
@@ -213,6 +364,7 @@ func TestSQS_Start(t *testing.T) {
 		args           args
 		wantReceiveErr error
 		wantDeleteErr  error
+		wantNoErr      bool
 	}{
 		{
 			name: "shouldHandleMessage",
@@ -260,6 +412,23 @@ func TestSQS_Start(t *testing.T) {
 			wantReceiveErr: nil,
 			wantDeleteErr:  errors.New("fake delete error"),
 		},
+		{
+			name: "should continue when delete fails",
+			fields: fields{
+				config: &SQSConf{
+					Queue:                 queueUrl,
+					DeleteStrategy:        DeleteStrategyOnSuccess,
+					ContinueOnDeleteError: true,
+				},
+				svc: new(SqsMock),
+			},
+			args: args{
+				consumeFn: consumeTestFunc,
+			},
+			wantReceiveErr: nil,
+			wantDeleteErr:  errors.New("fake delete error"),
+			wantNoErr:      true,
+		},
 		{
 			name: "should context timeout",
 			fields: fields{
@@ -287,7 +456,8 @@ func TestSQS_Start(t *testing.T) {
 			sqsMock.On("DeleteMessageBatch", mock.Anything, mock.AnythingOfType("*sqs.DeleteMessageBatchInput"),
 				mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, tt.wantDeleteErr)
 
-			ctx, _ := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
 
 			// set Env
 			setEnv("AWS_REGION", "baz", "AWS_SECRET_ACCESS_KEY", "foo", "AWS_ACCESS_KEY_ID", "bar")
@@ -304,7 +474,10 @@ func TestSQS_Start(t *testing.T) {
 			t.Log(len(actualData))
 			t.Log(len(actualAttributes))
 			mocked := tt.fields.svc.(*SqsMock)
-			if tt.wantReceiveErr == nil && tt.wantDeleteErr == nil {
+			if tt.wantNoErr {
+				assert.Nil(t, err)
+				assert.Greater(t, len(mocked.deleteInputs), 1)
+			} else if tt.wantReceiveErr == nil && tt.wantDeleteErr == nil {
 				assert.NotNil(t, mocked.inputs)
 				assert.NotNil(t, mocked.deleteInputs)
 				for _, msg := range actualData {
@@ -334,8 +507,9 @@ func getQueueContent() *sqs.ReceiveMessageOutput {
 	return &sqs.ReceiveMessageOutput{
 		Messages: []types.Message{
 			{
-				MessageId: aws.String("msg1"),
-				Body:      aws.String("msg1"),
+				MessageId:     aws.String("msg1"),
+				ReceiptHandle: aws.String("handle1"),
+				Body:          aws.String("msg1"),
 				MessageAttributes: map[string]types.MessageAttributeValue{
 					"attribute1": {
 						DataType:    aws.String("String"),
@@ -344,8 +518,9 @@ func getQueueContent() *sqs.ReceiveMessageOutput {
 				},
 			},
 			{
-				MessageId: aws.String("msg2"),
-				Body:      aws.String("msg2"),
+				MessageId:     aws.String("msg2"),
+				ReceiptHandle: aws.String("handle2"),
+				Body:          aws.String("msg2"),
 				MessageAttributes: map[string]types.MessageAttributeValue{
 					"attribute2": {
 						DataType:    aws.String("String"),
@@ -354,8 +529,9 @@ func getQueueContent() *sqs.ReceiveMessageOutput {
 				},
 			},
 			{
-				MessageId: aws.String("msg3"),
-				Body:      aws.String("msg3"),
+				MessageId:     aws.String("msg3"),
+				ReceiptHandle: aws.String("handle3"),
+				Body:          aws.String("msg3"),
 				MessageAttributes: map[string]types.MessageAttributeValue{
 					"attribute3": {
 						DataType:    aws.String("String"),
@@ -367,3 +543,686 @@ func getQueueContent() *sqs.ReceiveMessageOutput {
 	}
 
 }
+
+func TestSQS_WaitReady(t *testing.T) {
+	sqsMock := new(SqsMock)
+	sqsMock.On("ReceiveMessage", mock.Anything, mock.AnythingOfType("*sqs.ReceiveMessageInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+	sqsMock.On("DeleteMessageBatch", mock.Anything, mock.AnythingOfType("*sqs.DeleteMessageBatchInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+
+	s := &SQS{config: &SQSConf{Queue: "queue", Concurrency: 1, DeleteStrategy: DeleteStrategyImmediate}, sqs: sqsMock}
+
+	notStarted, cancelNotStarted := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelNotStarted()
+	require.ErrorIs(t, s.WaitReady(notStarted), context.DeadlineExceeded)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- s.Start(ctx, func(data []byte, attributes map[string]types.MessageAttributeValue) error {
+			return nil
+		})
+	}()
+
+	waitCtx, cancelWait := context.WithTimeout(context.Background(), time.Second)
+	defer cancelWait()
+	require.NoError(t, s.WaitReady(waitCtx))
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestSQS_StopCancelsConsumption(t *testing.T) {
+	sqsMock := new(SqsMock)
+	sqsMock.On("ReceiveMessage", mock.Anything, mock.AnythingOfType("*sqs.ReceiveMessageInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+	sqsMock.On("DeleteMessageBatch", mock.Anything, mock.AnythingOfType("*sqs.DeleteMessageBatchInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+
+	s := &SQS{config: &SQSConf{Queue: "queue", Concurrency: 1, DeleteStrategy: DeleteStrategyOnSuccess}, sqs: sqsMock}
+
+	consuming := make(chan struct{}, 3)
+	done := make(chan error)
+	go func() {
+		done <- s.StartWithContext(context.Background(), func(ctx context.Context, data []byte, attributes map[string]types.MessageAttributeValue) error {
+			consuming <- struct{}{}
+			<-ctx.Done()
+			return ctx.Err()
+		})
+	}()
+
+	<-consuming
+	s.Stop()
+	require.NoError(t, <-done)
+
+	assert.Empty(t, sqsMock.deleteInputs)
+	assert.Equal(t, int64(0), s.Stats().FailedTotal)
+}
+
+func TestSQS_StopDrainsConsumption(t *testing.T) {
+	sqsMock := new(SqsMock)
+	sqsMock.On("ReceiveMessage", mock.Anything, mock.AnythingOfType("*sqs.ReceiveMessageInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+	sqsMock.On("DeleteMessageBatch", mock.Anything, mock.AnythingOfType("*sqs.DeleteMessageBatchInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+
+	s := &SQS{config: &SQSConf{Queue: "queue", Concurrency: 1, DeleteStrategy: DeleteStrategyOnSuccess,
+		DrainTimeout: time.Minute}, sqs: sqsMock}
+
+	consuming := make(chan struct{}, 3)
+	release := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- s.StartWithContext(context.Background(), func(ctx context.Context, data []byte, attributes map[string]types.MessageAttributeValue) error {
+			consuming <- struct{}{}
+			<-release
+			return ctx.Err()
+		})
+	}()
+
+	<-consuming
+	s.Stop()
+	close(release)
+	require.NoError(t, <-done)
+
+	// the received batch is consumed and deleted, without receiving another one
+	assert.Len(t, sqsMock.inputs, 1)
+	require.Len(t, sqsMock.deleteInputs, 1)
+	assert.Len(t, sqsMock.deleteInputs[0].Entries, 3)
+	assert.Equal(t, int64(3), s.Stats().ProcessedTotal)
+}
+
+func TestSQS_StopDuringThrottleBackoff(t *testing.T) {
+	sqsMock := new(SqsMock)
+	sqsMock.On("ReceiveMessage", mock.Anything, mock.AnythingOfType("*sqs.ReceiveMessageInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, &types.OverLimit{})
+
+	s := &SQS{config: &SQSConf{Queue: "queue", Concurrency: 1, DeleteStrategy: DeleteStrategyOnSuccess,
+		ThrottleBackoff: time.Minute, MaxThrottleBackoff: time.Minute, DrainTimeout: time.Minute}, sqs: sqsMock}
+
+	done := make(chan error)
+	go func() {
+		done <- s.StartWithContext(context.Background(), func(context.Context, []byte, map[string]types.MessageAttributeValue) error {
+			return nil
+		})
+	}()
+
+	require.Eventually(t, func() bool {
+		sqsMock.mu.Lock()
+		defer sqsMock.mu.Unlock()
+		return len(sqsMock.inputs) > 0
+	}, time.Second, time.Millisecond)
+	s.Stop()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Stop waited for the throttle backoff")
+	}
+}
+
+func TestSQS_StopCancelsConsumptionAfterDrainTimeout(t *testing.T) {
+	sqsMock := new(SqsMock)
+	sqsMock.On("ReceiveMessage", mock.Anything, mock.AnythingOfType("*sqs.ReceiveMessageInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+
+	s := &SQS{config: &SQSConf{Queue: "queue", Concurrency: 1, DeleteStrategy: DeleteStrategyOnSuccess,
+		DrainTimeout: time.Minute}, sqs: sqsMock, clock: &fakeClock{now: time.Unix(1700000000, 0)}}
+
+	consuming := make(chan struct{}, 3)
+	done := make(chan error)
+	go func() {
+		done <- s.StartWithContext(context.Background(), func(ctx context.Context, data []byte, attributes map[string]types.MessageAttributeValue) error {
+			consuming <- struct{}{}
+			<-ctx.Done()
+			return ctx.Err()
+		})
+	}()
+
+	<-consuming
+	s.Stop()
+	require.NoError(t, <-done)
+
+	assert.Empty(t, sqsMock.deleteInputs)
+}
+
+// timedReceiveMock records the time of every receive.
+type timedReceiveMock struct {
+	*SqsMock
+	clk   *fakeClock
+	times []time.Time
+}
+
+func (m *timedReceiveMock) ReceiveMessage(_ context.Context, _ *sqs.ReceiveMessageInput, _ ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+	m.times = append(m.times, m.clk.Now())
+	return getQueueContent(), nil
+}
+
+func TestSQS_MinPollInterval(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	tests := []struct {
+		name      string
+		interval  time.Duration
+		wantTimes []time.Duration
+	}{
+		{name: "shouldNotPaceByDefault", wantTimes: []time.Duration{0, 300 * time.Millisecond, 600 * time.Millisecond}},
+		{name: "shouldPaceReceives", interval: time.Second, wantTimes: []time.Duration{0, time.Second, 2 * time.Second}},
+		{name: "shouldNotDelaySlowCycles", interval: 200 * time.Millisecond, wantTimes: []time.Duration{0, 300 * time.Millisecond, 600 * time.Millisecond}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clk := &fakeClock{now: start}
+			sqsMock := &timedReceiveMock{SqsMock: new(SqsMock), clk: clk}
+			sqsMock.On("DeleteMessageBatch", mock.Anything, mock.AnythingOfType("*sqs.DeleteMessageBatchInput"),
+				mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+
+			s := &SQS{sqs: sqsMock, clock: clk, config: &SQSConf{
+				Queue:           "queue",
+				DeleteStrategy:  DeleteStrategyOnSuccess,
+				MinPollInterval: tt.interval,
+			}}
+
+			consumeFn := func(ctx context.Context, _ []byte, _ map[string]types.MessageAttributeValue) error {
+				clk.Sleep(ctx, 100*time.Millisecond)
+				return nil
+			}
+			w := &worker{}
+			for range tt.wantTimes {
+				_, err := s.pollCycle(context.Background(), s.consumeEach(consumeFn), w)
+				require.NoError(t, err)
+			}
+
+			times := make([]time.Duration, len(sqsMock.times))
+			for i, at := range sqsMock.times {
+				times[i] = at.Sub(start)
+			}
+			assert.Equal(t, tt.wantTimes, times)
+		})
+	}
+}
+
+func TestSQS_emptyReceiveDelay(t *testing.T) {
+	s := &SQS{config: &SQSConf{}}
+	assert.Equal(t, time.Second, s.emptyReceiveDelay())
+
+	s.config.EmptyReceiveJitter = 100 * time.Millisecond
+	delays := map[time.Duration]bool{}
+	for i := 0; i < 20; i++ {
+		d := s.emptyReceiveDelay()
+		assert.GreaterOrEqual(t, d, time.Second)
+		assert.Less(t, d, 1100*time.Millisecond)
+		delays[d] = true
+	}
+	assert.Greater(t, len(delays), 1, "delays must be spread")
+}
+
+func TestSQS_Close(t *testing.T) {
+	var _ io.Closer = (*SQS)(nil)
+
+	sqsMock := new(SqsMock)
+	sqsMock.On("ReceiveMessage", mock.Anything, mock.AnythingOfType("*sqs.ReceiveMessageInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+
+	s := &SQS{config: &SQSConf{Queue: "queue", Concurrency: 1, DeleteStrategy: DeleteStrategyOnSuccess}, sqs: sqsMock}
+	require.NoError(t, s.Close())
+
+	consuming := make(chan struct{}, 3)
+	done := make(chan error, 1)
+	go func() {
+		done <- s.StartWithContext(context.Background(), func(ctx context.Context, data []byte, attributes map[string]types.MessageAttributeValue) error {
+			consuming <- struct{}{}
+			<-ctx.Done()
+			return ctx.Err()
+		})
+	}()
+
+	<-consuming
+	require.NoError(t, s.Close())
+	require.NoError(t, <-done)
+	assert.Equal(t, int64(0), s.Stats().FailedTotal)
+}
+
+func TestSQS_ShutdownSignals(t *testing.T) {
+	sqsMock := new(SqsMock)
+	sqsMock.On("ReceiveMessage", mock.Anything, mock.AnythingOfType("*sqs.ReceiveMessageInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+
+	s := &SQS{config: &SQSConf{
+		Queue:           "queue",
+		Concurrency:     1,
+		DeleteStrategy:  DeleteStrategyOnSuccess,
+		ShutdownSignals: []os.Signal{syscall.SIGUSR1},
+	}, sqs: sqsMock}
+
+	consuming := make(chan struct{}, 3)
+	done := make(chan error)
+	go func() {
+		done <- s.StartWithContext(context.Background(), func(ctx context.Context, data []byte, attributes map[string]types.MessageAttributeValue) error {
+			consuming <- struct{}{}
+			<-ctx.Done()
+			return ctx.Err()
+		})
+	}()
+
+	<-consuming
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGUSR1))
+	require.NoError(t, <-done)
+
+	assert.Equal(t, DefaultShutdownSignals, (&SQSConf{}).shutdownSignals())
+}
+
+func TestSQS_Flush(t *testing.T) {
+	sqsMock := new(SqsMock)
+	sqsMock.On("DeleteMessageBatch", mock.Anything, mock.AnythingOfType("*sqs.DeleteMessageBatchInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+
+	s := &SQS{config: &SQSConf{Queue: "queue", DeleteStrategy: DeleteStrategyBatched}, sqs: sqsMock}
+
+	msg := getQueueContent().Messages
+	require.NoError(t, s.bufferDeletes(context.Background(), msg))
+	assert.Nil(t, sqsMock.deleteInputs)
+
+	require.NoError(t, s.Flush(context.Background()))
+	require.Len(t, sqsMock.deleteInputs, 1)
+	assert.Len(t, sqsMock.deleteInputs[0].Entries, len(msg))
+	assert.Equal(t, int64(len(msg)), s.Stats().DeletedTotal)
+
+	require.NoError(t, s.Flush(context.Background()))
+	assert.Len(t, sqsMock.deleteInputs, 1)
+}
+
+func TestSQS_OnDelete(t *testing.T) {
+	sqsMock := &SqsMock{failDeleteID: "msg2"}
+	sqsMock.On("DeleteMessageBatch", mock.Anything, mock.AnythingOfType("*sqs.DeleteMessageBatchInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+
+	var deleted, failed []string
+	s := &SQS{config: &SQSConf{
+		Queue: "queue",
+		OnDelete: func(msgIDs []string) {
+			deleted = append(deleted, msgIDs...)
+		},
+		OnDeleteError: func(entries []types.BatchResultErrorEntry) {
+			for _, entry := range entries {
+				failed = append(failed, *entry.Id)
+			}
+		},
+	}, sqs: sqsMock}
+
+	require.NoError(t, s.deleteSqsMessages(context.Background(), getQueueContent().Messages))
+	assert.Equal(t, []string{"msg1", "msg3"}, deleted)
+	assert.Equal(t, []string{"msg2"}, failed)
+	assert.Equal(t, int64(2), s.Stats().DeletedTotal)
+	assert.Equal(t, int64(1), s.Stats().DeleteFailedTotal)
+}
+
+func TestSQS_consumeMessagesFiltered(t *testing.T) {
+	consumed := make([]string, 0)
+	consumeFn := func(_ context.Context, data []byte, attributes map[string]types.MessageAttributeValue) error {
+		consumed = append(consumed, string(data))
+		return nil
+	}
+
+	tests := []struct {
+		name         string
+		strategy     DeleteStrategy
+		filter       func(msg Message) bool
+		wantDelete   int
+		wantConsumed int
+	}{
+		{
+			name:         "shouldDeleteFilteredOnSuccess",
+			strategy:     DeleteStrategyOnSuccess,
+			filter:       func(msg Message) bool { return *msg.Body == "msg1" },
+			wantDelete:   3,
+			wantConsumed: 1,
+		},
+		{
+			name:         "shouldNotDeleteFilteredTwiceImmediate",
+			strategy:     DeleteStrategyImmediate,
+			filter:       func(msg Message) bool { return *msg.Body == "msg1" },
+			wantDelete:   0,
+			wantConsumed: 1,
+		},
+		{
+			name:         "shouldReportAllFiltered",
+			strategy:     DeleteStrategyOnSuccess,
+			filter:       func(msg Message) bool { return false },
+			wantDelete:   3,
+			wantConsumed: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			consumed = consumed[:0]
+			s := &SQS{config: &SQSConf{Queue: "queue", DeleteStrategy: tt.strategy, Filter: tt.filter}}
+
+			toDelete, n := s.consumeMessages(context.Background(), &worker{}, getQueueContent().Messages, s.consumeEach(consumeFn))
+			assert.Len(t, toDelete, tt.wantDelete)
+			assert.Equal(t, tt.wantConsumed, n)
+			assert.Len(t, consumed, tt.wantConsumed)
+			assert.Equal(t, int64(3-tt.wantConsumed), s.Stats().FilteredTotal)
+		})
+	}
+}
+
+func TestSQS_pollCycleImmediateSkipped(t *testing.T) {
+	tests := []struct {
+		name string
+		conf SQSConf
+	}{
+		{name: "shouldDeleteFilteredOnce", conf: SQSConf{Filter: func(msg Message) bool { return *msg.Body == "msg1" }}},
+		{name: "shouldDeleteOversizedOnce", conf: SQSConf{MaxBodyBytes: 1, OversizedBodyAction: DecodeErrorDelete}},
+		{name: "shouldDeleteKeptOversizedOnce", conf: SQSConf{MaxBodyBytes: 1, OversizedBodyAction: DecodeErrorKeep}},
+		{name: "shouldDeleteDuplicatesOnce", conf: SQSConf{DedupWindow: time.Minute}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqsMock := new(SqsMock)
+			sqsMock.On("ReceiveMessage", mock.Anything, mock.AnythingOfType("*sqs.ReceiveMessageInput"),
+				mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+			sqsMock.On("DeleteMessageBatch", mock.Anything, mock.AnythingOfType("*sqs.DeleteMessageBatchInput"),
+				mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+
+			conf := tt.conf
+			conf.Queue = "queue"
+			conf.DeleteStrategy = DeleteStrategyImmediate
+			s := &SQS{sqs: sqsMock, config: &conf, clock: &fakeClock{now: time.Unix(1700000000, 0)}}
+
+			consumeFn := func(_ context.Context, _ []byte, _ map[string]types.MessageAttributeValue) error { return nil }
+			for range 2 {
+				_, err := s.pollCycle(context.Background(), s.consumeEach(consumeFn), &worker{})
+				require.NoError(t, err)
+			}
+
+			require.Len(t, sqsMock.deleteInputs, 2)
+			for _, input := range sqsMock.deleteInputs {
+				ids := make([]string, len(input.Entries))
+				for i, entry := range input.Entries {
+					ids[i] = aws.ToString(entry.Id)
+				}
+				assert.Equal(t, []string{"msg1", "msg2", "msg3"}, ids)
+			}
+		})
+	}
+}
+
+func TestSQS_StartBatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		strategy   DeleteStrategy
+		consumeFn  BatchConsumerFn
+		wantDelete []string
+	}{
+		{
+			name:     "shouldDeleteReturnedIds",
+			strategy: DeleteStrategyOnSuccess,
+			consumeFn: func(msgs []Message) ([]string, error) {
+				return []string{*msgs[0].MessageId, *msgs[2].MessageId}, nil
+			},
+			wantDelete: []string{"msg1", "msg3"},
+		},
+		{
+			name:     "shouldDeleteReturnedIdsOnError",
+			strategy: DeleteStrategyOnSuccess,
+			consumeFn: func(msgs []Message) ([]string, error) {
+				return []string{*msgs[1].MessageId}, errors.New("fake consume error")
+			},
+			wantDelete: []string{"msg2"},
+		},
+		{
+			name:     "shouldIgnoreUnknownIds",
+			strategy: DeleteStrategyOnSuccess,
+			consumeFn: func(msgs []Message) ([]string, error) {
+				return []string{"unknown"}, nil
+			},
+			wantDelete: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &SQS{config: &SQSConf{Queue: "queue", DeleteStrategy: tt.strategy}}
+
+			toDelete, consumed := s.consumeMessages(context.Background(), &worker{}, getQueueContent().Messages, s.consumeBatch(tt.consumeFn))
+			assert.Equal(t, 3, consumed)
+
+			ids := make([]string, 0)
+			for _, msg := range toDelete {
+				ids = append(ids, *msg.MessageId)
+			}
+			assert.Equal(t, tt.wantDelete, ids)
+			assert.Equal(t, int64(len(tt.wantDelete)), s.Stats().ProcessedTotal)
+			assert.Equal(t, int64(3-len(tt.wantDelete)), s.Stats().FailedTotal)
+		})
+	}
+}
+
+func TestSQS_SlowHandlerThreshold(t *testing.T) {
+	logs := &bytes.Buffer{}
+	s := &SQS{config: &SQSConf{
+		Queue:                "queue",
+		DeleteStrategy:       DeleteStrategyOnSuccess,
+		SlowHandlerThreshold: 5 * time.Millisecond,
+		Logger:               slog.New(slog.NewTextHandler(logs, nil)),
+	}}
+
+	consumeFn := func(_ context.Context, data []byte, attributes map[string]types.MessageAttributeValue) error {
+		if string(data) == "msg2" {
+			time.Sleep(10 * time.Millisecond)
+		}
+		return nil
+	}
+
+	s.consumeMessages(context.Background(), &worker{}, getQueueContent().Messages, s.consumeEach(consumeFn))
+	assert.Equal(t, 1, strings.Count(logs.String(), "slow consume function"))
+	assert.Contains(t, logs.String(), "messageId=msg2")
+}
+
+func TestSQS_consumeMessagesSorted(t *testing.T) {
+	consumed := make([]string, 0)
+	consumeFn := func(_ context.Context, data []byte, attributes map[string]types.MessageAttributeValue) error {
+		consumed = append(consumed, string(data))
+		return nil
+	}
+
+	s := &SQS{config: &SQSConf{
+		Queue:          "queue",
+		DeleteStrategy: DeleteStrategyOnSuccess,
+		BatchSorter: func(msgs []Message) []Message {
+			sort.SliceStable(msgs, func(i, j int) bool { return *msgs[i].Body > *msgs[j].Body })
+			return msgs
+		},
+	}}
+
+	toDelete, n := s.consumeMessages(context.Background(), &worker{}, getQueueContent().Messages, s.consumeEach(consumeFn))
+	assert.Equal(t, 3, n)
+	assert.Len(t, toDelete, 3)
+	assert.Equal(t, []string{"msg3", "msg2", "msg1"}, consumed)
+}
+
+// longPollMock blocks every receive until its context is done, like a long poll on an empty queue.
+type longPollMock struct {
+	*SqsMock
+}
+
+func (m longPollMock) ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestSQS_StopAbortsLongPoll(t *testing.T) {
+	s := &SQS{config: &SQSConf{Queue: "queue", Concurrency: 2, WaitTimeSeconds: 20}, sqs: longPollMock{new(SqsMock)}}
+
+	done := make(chan error)
+	go func() {
+		done <- s.Start(context.Background(), func(data []byte, attributes map[string]types.MessageAttributeValue) error {
+			return nil
+		})
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, s.WaitReady(ctx))
+
+	stopped := time.Now()
+	s.Stop()
+	require.NoError(t, <-done)
+	assert.Less(t, time.Since(stopped), time.Second)
+}
+
+func TestSQS_consumeMessagesStreamBatches(t *testing.T) {
+	messages := getQueueContent().Messages
+
+	consumeFn := func(_ context.Context, data []byte, attributes map[string]types.MessageAttributeValue) error {
+		for _, msg := range messages {
+			assert.Nil(t, msg.Body, "received messages must be released")
+		}
+		return nil
+	}
+
+	s := &SQS{config: &SQSConf{Queue: "queue", DeleteStrategy: DeleteStrategyOnSuccess, StreamBatches: true}}
+
+	toDelete, n := s.consumeMessages(context.Background(), &worker{}, messages, s.consumeEach(consumeFn))
+	assert.Equal(t, 3, n)
+	require.Len(t, toDelete, 3)
+	for _, msg := range toDelete {
+		assert.NotNil(t, msg.MessageId)
+		assert.Nil(t, msg.Body)
+		assert.Nil(t, msg.MessageAttributes)
+	}
+}
+
+func TestSQS_consumeMessagesConcurrently(t *testing.T) {
+	var running, peak atomic.Int32
+	consumeFn := func(_ context.Context, data []byte, attributes map[string]types.MessageAttributeValue) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	}
+
+	s := &SQS{config: &SQSConf{Queue: "queue", DeleteStrategy: DeleteStrategyOnSuccess, ConsumeConcurrently: true, MaxInFlight: 2}}
+
+	messages := getQueueContent().Messages
+	toDelete, n := s.consumeMessages(context.Background(), &worker{}, messages, s.consumeEach(consumeFn))
+	assert.Equal(t, 3, n)
+	assert.Equal(t, messages, toDelete)
+	assert.Equal(t, int32(2), peak.Load())
+}
+
+func TestSQS_AfterPoll(t *testing.T) {
+	sqsMock := new(SqsMock)
+	sqsMock.On("ReceiveMessage", mock.Anything, mock.AnythingOfType("*sqs.ReceiveMessageInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+	sqsMock.On("DeleteMessageBatch", mock.Anything, mock.AnythingOfType("*sqs.DeleteMessageBatchInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, errors.New("fake delete error"))
+
+	var polls []int
+	var pollErr error
+	s := &SQS{sqs: sqsMock, config: &SQSConf{
+		Queue:          "queue",
+		Concurrency:    1,
+		DeleteStrategy: DeleteStrategyOnSuccess,
+		AfterPoll: func(processed int, err error) {
+			polls = append(polls, processed)
+			pollErr = err
+		},
+	}}
+
+	err := s.Start(context.Background(), func(data []byte, attributes map[string]types.MessageAttributeValue) error {
+		return nil
+	})
+	require.Error(t, err)
+
+	assert.Equal(t, []int{3}, polls)
+	assert.Equal(t, err, pollErr)
+}
+
+func TestSQS_ProcessDecider(t *testing.T) {
+	tests := []struct {
+		name         string
+		declined     bool
+		wantConsumed []string
+		wantDeclined []string
+		wantDeleted  []string
+		wantReleased []string
+	}{
+		{
+			name:         "shouldReleaseDeclinedMessages",
+			wantConsumed: []string{"msg2"},
+			wantDeleted:  []string{"msg2"},
+			wantReleased: []string{"msg1", "msg3"},
+		},
+		{
+			name:         "shouldConsumeDeclinedMessagesWithDeclinedConsumer",
+			declined:     true,
+			wantConsumed: []string{"msg2"},
+			wantDeclined: []string{"msg1", "msg3"},
+			wantDeleted:  []string{"msg1", "msg3", "msg2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqsMock := new(SqsMock)
+			sqsMock.On("ReceiveMessage", mock.Anything, mock.AnythingOfType("*sqs.ReceiveMessageInput"),
+				mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+			sqsMock.On("ChangeMessageVisibilityBatch", mock.Anything, mock.AnythingOfType("*sqs.ChangeMessageVisibilityBatchInput"),
+				mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+			sqsMock.On("DeleteMessageBatch", mock.Anything, mock.AnythingOfType("*sqs.DeleteMessageBatchInput"),
+				mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+
+			var consumed, declined []string
+			s := &SQS{sqs: sqsMock, config: &SQSConf{
+				Queue:          "queue",
+				DeleteStrategy: DeleteStrategyOnSuccess,
+				ProcessDecider: func(msg Message) bool {
+					return aws.ToString(msg.MessageId) == "msg2"
+				},
+			}}
+			if tt.declined {
+				s.config.DeclinedConsumer = func(_ context.Context, data []byte, _ map[string]types.MessageAttributeValue) error {
+					declined = append(declined, string(data))
+					return nil
+				}
+			}
+
+			consumeFn := func(_ context.Context, data []byte, _ map[string]types.MessageAttributeValue) error {
+				consumed = append(consumed, string(data))
+				return nil
+			}
+
+			processed, err := s.pollCycle(context.Background(), s.consumeEach(consumeFn), &worker{})
+			require.NoError(t, err)
+			assert.Equal(t, 1+len(tt.wantDeclined), processed)
+			assert.Equal(t, tt.wantConsumed, consumed)
+			assert.Equal(t, tt.wantDeclined, declined)
+			assert.Equal(t, int64(2), s.Stats().DeclinedTotal)
+
+			var deleted []string
+			for _, input := range sqsMock.deleteInputs {
+				for _, entry := range input.Entries {
+					deleted = append(deleted, aws.ToString(entry.Id))
+				}
+			}
+			assert.Equal(t, tt.wantDeleted, deleted)
+
+			var released []string
+			for _, input := range sqsMock.visibilityBatchInputs {
+				for _, entry := range input.Entries {
+					released = append(released, aws.ToString(entry.Id))
+					assert.Equal(t, int32(0), entry.VisibilityTimeout)
+				}
+			}
+			assert.Equal(t, tt.wantReleased, released)
+		})
+	}
+}
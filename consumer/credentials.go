@@ -0,0 +1,69 @@
+package consumer
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/endpointcreds"
+	"os"
+	"strings"
+)
+
+// containerCredentialsHost serves the ECS container credentials of AWS_CONTAINER_CREDENTIALS_RELATIVE_URI.
+const containerCredentialsHost = "http://169.254.170.2"
+
+// credentialsOptions returns the config options selecting the credentials of NewSQSConsumer: CredentialsProvider
+// when set, the static AWS environment keys when set, the container credentials endpoint when ContainerCredentials
+// is enabled and available, the default credential chain otherwise.
+func credentialsOptions(conf *SQSConf) []func(*config.LoadOptions) error {
+	var opts []func(*config.LoadOptions) error
+	if region := os.Getenv("AWS_REGION"); region != "" {
+		opts = append(opts, config.WithRegion(region))
+	}
+
+	if conf != nil && conf.CredentialsProvider != nil {
+		provider := conf.CredentialsProvider
+		if _, cached := provider.(*aws.CredentialsCache); !cached {
+			provider = aws.NewCredentialsCache(provider)
+		}
+		return append(opts, config.WithCredentialsProvider(provider))
+	}
+
+	if os.Getenv("AWS_ACCESS_KEY_ID") != "" && os.Getenv("AWS_SECRET_ACCESS_KEY") != "" {
+		cred := credentials.NewStaticCredentialsProvider(os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"), os.Getenv("AWS_SESSION_TOKEN"))
+		return append(opts, config.WithCredentialsProvider(cred))
+	}
+
+	if conf != nil && conf.ContainerCredentials {
+		if provider, ok := containerCredentialsProvider(); ok {
+			return append(opts, config.WithCredentialsProvider(provider))
+		}
+	}
+
+	return opts
+}
+
+// containerCredentialsProvider returns a provider for the ECS container credentials endpoint, the relative
+// URI taking precedence over the full one like in the default chain.
+func containerCredentialsProvider() (aws.CredentialsProvider, bool) {
+	endpoint := os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI")
+	if relative := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); relative != "" {
+		endpoint = containerCredentialsHost + relative
+	}
+
+	if endpoint == "" {
+		return nil, false
+	}
+
+	provider := endpointcreds.New(endpoint, func(o *endpointcreds.Options) {
+		o.AuthorizationToken = os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN")
+		if file := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"); file != "" {
+			o.AuthorizationTokenProvider = endpointcreds.TokenProviderFunc(func() (string, error) {
+				token, err := os.ReadFile(file)
+				return strings.TrimSpace(string(token)), err
+			})
+		}
+	})
+
+	return aws.NewCredentialsCache(provider), true
+}
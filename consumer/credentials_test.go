@@ -0,0 +1,86 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestContainerCredentialsProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "token", r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(`{"AccessKeyId":"container-key","SecretAccessKey":"container-secret","Token":"session"}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	t.Setenv("AWS_REGION", "eu-west-1")
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "")
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", server.URL)
+	t.Setenv("AWS_CONTAINER_AUTHORIZATION_TOKEN", "token")
+
+	opts := credentialsOptions(&SQSConf{ContainerCredentials: true})
+	cfg, err := config.LoadDefaultConfig(context.Background(), opts...)
+	require.NoError(t, err)
+
+	creds, err := cfg.Credentials.Retrieve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "container-key", creds.AccessKeyID)
+	assert.Equal(t, "session", creds.SessionToken)
+}
+
+func TestCredentialsOptions(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "")
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", "")
+
+	_, ok := containerCredentialsProvider()
+	assert.False(t, ok)
+	assert.Empty(t, credentialsOptions(&SQSConf{ContainerCredentials: true}))
+
+	t.Setenv("AWS_REGION", "eu-west-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", "key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	assert.Len(t, credentialsOptions(nil), 2)
+}
+
+// rotatingProvider returns new credentials, expiring at once, on every call.
+type rotatingProvider struct {
+	calls int
+}
+
+func (p *rotatingProvider) Retrieve(context.Context) (aws.Credentials, error) {
+	p.calls++
+	return aws.Credentials{
+		AccessKeyID:     fmt.Sprintf("key%d", p.calls),
+		SecretAccessKey: "secret",
+		CanExpire:       true,
+		Expires:         time.Now().Add(-time.Second),
+	}, nil
+}
+
+func TestCredentialsOptionsProvider(t *testing.T) {
+	t.Setenv("AWS_REGION", "eu-west-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", "key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+
+	provider := &rotatingProvider{}
+	cfg, err := config.LoadDefaultConfig(context.Background(), credentialsOptions(&SQSConf{CredentialsProvider: provider})...)
+	require.NoError(t, err)
+
+	for _, want := range []string{"key1", "key2"} {
+		creds, err := cfg.Credentials.Retrieve(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, want, creds.AccessKeyID, "expired credentials are retrieved again")
+	}
+}
@@ -0,0 +1,53 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// redrivePolicy is the RedrivePolicy attribute of a queue.
+type redrivePolicy struct {
+	DeadLetterTargetArn string `json:"deadLetterTargetArn"`
+}
+
+// discoverDeadLetterQueue sets DeadLetterQueueURL to the dead letter queue of the RedrivePolicy of the queue.
+func (s *SQS) discoverDeadLetterQueue(ctx context.Context) error {
+	out, err := s.sqs.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       aws.String(s.config.Queue),
+		AttributeNames: []types.QueueAttributeName{types.QueueAttributeNameRedrivePolicy},
+	})
+	if err != nil {
+		return err
+	}
+
+	raw := out.Attributes[string(types.QueueAttributeNameRedrivePolicy)]
+	if raw == "" {
+		return fmt.Errorf("%w: queue has no redrive policy", SentinelErrorDeadLetterQueueNotSet)
+	}
+
+	var policy redrivePolicy
+	if err := json.Unmarshal([]byte(raw), &policy); err != nil {
+		return fmt.Errorf("reading redrive policy: %w", err)
+	}
+
+	target, err := arn.Parse(policy.DeadLetterTargetArn)
+	if err != nil {
+		return fmt.Errorf("reading redrive policy dead letter target: %w", err)
+	}
+
+	url, err := s.sqs.GetQueueUrl(ctx, &sqs.GetQueueUrlInput{
+		QueueName:              aws.String(target.Resource),
+		QueueOwnerAWSAccountId: aws.String(target.AccountID),
+	})
+	if err != nil {
+		return err
+	}
+
+	s.config.DeadLetterQueueURL = aws.ToString(url.QueueUrl)
+	return nil
+}
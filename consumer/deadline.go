@@ -0,0 +1,35 @@
+package consumer
+
+import (
+	"context"
+	"time"
+)
+
+// visibilityDeadline returns the context of a consumption bounded by the visibility expiry of its message, less
+// VisibilityDeadlineMargin, when VisibilityDeadline is enabled. The messages deleted on receipt have no deadline.
+func (s *SQS) visibilityDeadline(ctx context.Context, w *worker) (context.Context, context.CancelFunc) {
+	if !s.config.VisibilityDeadline || s.strategy(w) == DeleteStrategyImmediate {
+		return ctx, func() {}
+	}
+
+	margin := s.config.VisibilityDeadlineMargin
+	if margin == 0 {
+		margin = DefaultVisibilityDeadlineMargin
+	}
+
+	expiry := w.received.Add(s.visibility(w) - margin)
+	return context.WithTimeout(ctx, max(0, expiry.Sub(s.now())))
+}
+
+// visibility returns how long the messages received by the worker stay hidden.
+func (s *SQS) visibility(w *worker) time.Duration {
+	switch {
+	case s.heartbeat(w):
+		// the heartbeat keeps the message hidden up to the SQS limit
+		return maxVisibilityTimeout
+	case s.config.InitialVisibilityExtension > 0:
+		return s.config.InitialVisibilityExtension
+	default:
+		return s.config.visibilityTimeout()
+	}
+}
@@ -0,0 +1,58 @@
+package consumer
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestSQS_visibilityDeadline(t *testing.T) {
+	tests := []struct {
+		name         string
+		conf         SQSConf
+		wantDeadline time.Duration
+	}{
+		{name: "shouldNotSetByDefault", conf: SQSConf{DeleteStrategy: DeleteStrategyOnSuccess}},
+		{name: "shouldNotSetWhenDeletedOnReceipt", conf: SQSConf{DeleteStrategy: DeleteStrategyImmediate, VisibilityDeadline: true}},
+		{
+			name:         "shouldUseVisibilityTimeout",
+			conf:         SQSConf{DeleteStrategy: DeleteStrategyOnSuccess, VisibilityDeadline: true, VisibilityTimeout: 10},
+			wantDeadline: 8 * time.Second,
+		},
+		{
+			name:         "shouldUseMargin",
+			conf:         SQSConf{DeleteStrategy: DeleteStrategyOnSuccess, VisibilityDeadline: true, VisibilityDeadlineMargin: 5 * time.Second},
+			wantDeadline: 25 * time.Second,
+		},
+		{
+			name:         "shouldUseInitialExtension",
+			conf:         SQSConf{DeleteStrategy: DeleteStrategyOnSuccess, VisibilityDeadline: true, InitialVisibilityExtension: time.Minute},
+			wantDeadline: 58 * time.Second,
+		},
+		{
+			name:         "shouldExtendWithHeartbeat",
+			conf:         SQSConf{DeleteStrategy: DeleteStrategyOnSuccess, VisibilityDeadline: true, VisibilityHeartbeat: 10 * time.Second},
+			wantDeadline: maxVisibilityTimeout - 2*time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clk := &fakeClock{now: time.Unix(1700000000, 0)}
+			s := &SQS{config: &tt.conf, clock: clk}
+			w := &worker{received: clk.Now()}
+
+			ctx, cancel := s.visibilityDeadline(context.Background(), w)
+			defer cancel()
+
+			deadline, ok := ctx.Deadline()
+			if tt.wantDeadline == 0 {
+				assert.False(t, ok)
+				return
+			}
+			assert.True(t, ok)
+			assert.WithinDuration(t, time.Now().Add(tt.wantDeadline), deadline, time.Second)
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"log/slog"
+)
+
+const (
+	// DecodeErrorDelete deletes the messages that fail to decode.
+	DecodeErrorDelete = DecodeErrorAction("DELETE")
+	// DecodeErrorKeep leaves the messages that fail to decode for redelivery, until the queue redrive policy moves them.
+	DecodeErrorKeep = DecodeErrorAction("KEEP")
+	// DecodeErrorDeadLetter sends the messages that fail to decode to DeadLetterQueueURL and deletes them.
+	DecodeErrorDeadLetter = DecodeErrorAction("DEAD_LETTER")
+)
+
+// DecodeErrorAction is what happens to a message whose consumer function returned a DecodeError.
+type DecodeErrorAction string
+
+// DecodeError is returned by consumer functions for messages that can't be decoded. Such messages are poison:
+// they are handled according to DecodeErrorAction instead of being redelivered until they expire.
+type DecodeError struct {
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	return "decoding message: " + e.Err.Error()
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// Typed adapts a consumer function of JSON message bodies decoded into T. Bodies that fail to decode are
+// handled according to DecodeErrorAction.
+func Typed[T any](fn func(ctx context.Context, v T) error) ContextConsumerFn {
+	return func(ctx context.Context, data []byte, _ map[string]types.MessageAttributeValue) error {
+		var v T
+		if err := json.Unmarshal(data, &v); err != nil {
+			return &DecodeError{Err: err}
+		}
+		return fn(ctx, v)
+	}
+}
+
+// decodeErrorAction defaults to DecodeErrorDeadLetter when DeadLetterQueueURL is set, to DecodeErrorDelete otherwise.
+func (c *SQSConf) decodeErrorAction() DecodeErrorAction {
+	switch {
+	case c.DecodeErrorAction != "":
+		return c.DecodeErrorAction
+	case c.DeadLetterQueueURL != "":
+		return DecodeErrorDeadLetter
+	default:
+		return DecodeErrorDelete
+	}
+}
+
+// decodeFailed applies DecodeErrorAction when err is a DecodeError and reports whether the message must be deleted.
+func (s *SQS) decodeFailed(ctx context.Context, msg types.Message, err error) bool {
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) {
+		return false
+	}
+
+	switch s.config.decodeErrorAction() {
+	case DecodeErrorDeadLetter:
+		return s.applyResult(ctx, msg, Result{ToDLQ: true})
+	case DecodeErrorDelete:
+		s.logger().Warn("deleting message that failed to decode", slog.String("messageId", aws.ToString(msg.MessageId)))
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,65 @@
+package consumer
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func TestTyped(t *testing.T) {
+	type order struct {
+		ID string `json:"id"`
+	}
+
+	messages := []types.Message{
+		{MessageId: aws.String("msg1"), Body: aws.String(`{"id":"order1"}`)},
+		{MessageId: aws.String("msg2"), Body: aws.String(`not json`)},
+	}
+
+	tests := []struct {
+		name       string
+		action     DecodeErrorAction
+		dlq        string
+		wantDelete []string
+		wantDLQ    int
+	}{
+		{name: "shouldDeleteByDefault", wantDelete: []string{"msg1", "msg2"}},
+		{name: "shouldDeadLetterByDefaultWithDLQ", dlq: "dlq", wantDelete: []string{"msg1", "msg2"}, wantDLQ: 1},
+		{name: "shouldKeep", action: DecodeErrorKeep, dlq: "dlq", wantDelete: []string{"msg1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqsMock := new(SqsMock)
+			sqsMock.On("SendMessage", mock.Anything, mock.AnythingOfType("*sqs.SendMessageInput"),
+				mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+
+			s := &SQS{sqs: sqsMock, config: &SQSConf{
+				Queue:              "queue",
+				DeleteStrategy:     DeleteStrategyOnSuccess,
+				DeadLetterQueueURL: tt.dlq,
+				DecodeErrorAction:  tt.action,
+			}}
+
+			var got []string
+			consumeFn := Typed(func(_ context.Context, v order) error {
+				got = append(got, v.ID)
+				return nil
+			})
+
+			toDelete, _ := s.consumeMessages(context.Background(), &worker{}, messages, s.consumeEach(consumeFn))
+
+			ids := make([]string, len(toDelete))
+			for i, msg := range toDelete {
+				ids[i] = *msg.MessageId
+			}
+			assert.Equal(t, tt.wantDelete, ids)
+			assert.Equal(t, []string{"order1"}, got)
+			assert.Len(t, sqsMock.sendInputs, tt.wantDLQ)
+			assert.Equal(t, int64(1), s.Stats().FailedTotal)
+		})
+	}
+}
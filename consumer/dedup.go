@@ -0,0 +1,133 @@
+package consumer
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// IdempotencyStore records the idempotency keys of the consumed messages, typically in a store shared by every
+// consumer instance such as Redis or DynamoDB. Implementations must be safe for concurrent use.
+type IdempotencyStore interface {
+	// Seen reports whether key was marked and its ttl has not expired.
+	Seen(ctx context.Context, key string) (bool, error)
+	// Mark records key for ttl.
+	Mark(ctx context.Context, key string, ttl time.Duration) error
+}
+
+// IdempotencyByMessageID is the default IdempotencyKeyFunc: it deduplicates redeliveries of the same message.
+func IdempotencyByMessageID(msg Message) (string, bool) {
+	if msg.MessageId == nil {
+		return "", false
+	}
+	return *msg.MessageId, true
+}
+
+// memoryStore is the IdempotencyStore used when only DedupWindow is set. Expired keys are evicted on Mark.
+type memoryStore struct {
+	mu      sync.Mutex
+	expires map[string]time.Time
+	now     func() time.Time
+}
+
+func newMemoryStore(now func() time.Time) *memoryStore {
+	return &memoryStore{expires: make(map[string]time.Time), now: now}
+}
+
+func (c *memoryStore) Seen(_ context.Context, key string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires, ok := c.expires[key]
+	return ok && c.now().Before(expires), nil
+}
+
+func (c *memoryStore) Mark(_ context.Context, key string, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	for k, expires := range c.expires {
+		if !now.Before(expires) {
+			delete(c.expires, k)
+		}
+	}
+	c.expires[key] = now.Add(ttl)
+
+	return nil
+}
+
+type idempotency struct {
+	once  sync.Once
+	store IdempotencyStore
+}
+
+// idempotencyStore returns IdempotencyStore, an in-memory store when only DedupWindow is set, nil when
+// deduplication is disabled.
+func (s *SQS) idempotencyStore() IdempotencyStore {
+	s.idempotency.once.Do(func() {
+		switch {
+		case s.config.IdempotencyStore != nil:
+			s.idempotency.store = s.config.IdempotencyStore
+		case s.config.DedupWindow > 0:
+			s.idempotency.store = newMemoryStore(s.now)
+		}
+	})
+	return s.idempotency.store
+}
+
+func (s *SQS) idempotencyKey(msg types.Message) (string, bool) {
+	keyFn := s.config.IdempotencyKeyFunc
+	if keyFn == nil {
+		keyFn = IdempotencyByMessageID
+	}
+	return keyFn(s.message(msg))
+}
+
+// duplicate reports whether a message with the same idempotency key was consumed. Messages whose key can't be
+// checked are consumed.
+func (s *SQS) duplicate(ctx context.Context, msg types.Message) bool {
+	store := s.idempotencyStore()
+	if store == nil {
+		return false
+	}
+
+	key, ok := s.idempotencyKey(msg)
+	if !ok {
+		return false
+	}
+
+	seen, err := store.Seen(ctx, key)
+	if err != nil {
+		s.logger().Error("error checking idempotency key, consuming the message",
+			slog.String("messageId", aws.ToString(msg.MessageId)),
+			slog.Any("error", err.Error()))
+		return false
+	}
+
+	return seen
+}
+
+// remember marks the idempotency keys of the consumed messages for DedupWindow.
+func (s *SQS) remember(ctx context.Context, msgs []types.Message) {
+	store := s.idempotencyStore()
+	if store == nil {
+		return
+	}
+
+	for _, msg := range msgs {
+		key, ok := s.idempotencyKey(msg)
+		if !ok {
+			continue
+		}
+
+		if err := store.Mark(ctx, key, s.config.DedupWindow); err != nil {
+			s.logger().Error("error marking idempotency key",
+				slog.String("messageId", aws.ToString(msg.MessageId)),
+				slog.Any("error", err.Error()))
+		}
+	}
+}
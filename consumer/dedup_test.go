@@ -0,0 +1,141 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestSQS_consumeMessagesDedup(t *testing.T) {
+	tests := []struct {
+		name          string
+		keyFn         func(msg Message) (string, bool)
+		consumeErr    error
+		wantConsumed  int
+		wantDuplicate int64
+	}{
+		{
+			name:          "shouldSkipRedeliveredMessages",
+			wantConsumed:  3,
+			wantDuplicate: 3,
+		},
+		{
+			name: "shouldSkipSameBusinessKey",
+			keyFn: func(msg Message) (string, bool) {
+				v, ok := msg.MessageAttributes["attribute1"]
+				if !ok {
+					return "", false
+				}
+				return *v.StringValue, true
+			},
+			wantConsumed:  5,
+			wantDuplicate: 1,
+		},
+		{
+			name:         "shouldConsumeAgainFailedMessages",
+			consumeErr:   errors.New("fake consume error"),
+			wantConsumed: 6,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			consumed := 0
+			consumeFn := func(_ context.Context, data []byte, attributes map[string]types.MessageAttributeValue) error {
+				consumed++
+				return tt.consumeErr
+			}
+
+			s := &SQS{config: &SQSConf{
+				Queue:              "queue",
+				DeleteStrategy:     DeleteStrategyOnSuccess,
+				DedupWindow:        time.Minute,
+				IdempotencyKeyFunc: tt.keyFn,
+			}}
+
+			for i := 0; i < 2; i++ {
+				s.consumeMessages(context.Background(), &worker{}, getQueueContent().Messages, s.consumeEach(consumeFn))
+			}
+
+			assert.Equal(t, tt.wantConsumed, consumed)
+			assert.Equal(t, tt.wantDuplicate, s.Stats().DuplicateTotal)
+		})
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	now := time.Now()
+	c := newMemoryStore(func() time.Time { return now })
+	ctx := context.Background()
+
+	require.NoError(t, c.Mark(ctx, "key", time.Minute))
+
+	c.now = func() time.Time { return now.Add(30 * time.Second) }
+	seen, err := c.Seen(ctx, "key")
+	require.NoError(t, err)
+	assert.True(t, seen)
+
+	seen, _ = c.Seen(ctx, "other")
+	assert.False(t, seen)
+
+	c.now = func() time.Time { return now.Add(time.Minute) }
+	seen, _ = c.Seen(ctx, "key")
+	assert.False(t, seen)
+
+	require.NoError(t, c.Mark(ctx, "other", time.Minute))
+	assert.Len(t, c.expires, 1)
+}
+
+type fakeStore struct {
+	seen    map[string]bool
+	seenErr error
+	marked  map[string]time.Duration
+}
+
+func (f *fakeStore) Seen(_ context.Context, key string) (bool, error) {
+	return f.seen[key], f.seenErr
+}
+
+func (f *fakeStore) Mark(_ context.Context, key string, ttl time.Duration) error {
+	f.marked[key] = ttl
+	return nil
+}
+
+func TestSQS_IdempotencyStore(t *testing.T) {
+	tests := []struct {
+		name         string
+		seenErr      error
+		wantConsumed int
+	}{
+		{name: "shouldSkipSeenKeys", wantConsumed: 2},
+		{name: "shouldConsumeOnStoreError", seenErr: errors.New("fake store error"), wantConsumed: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeStore{seen: map[string]bool{"msg2": true}, seenErr: tt.seenErr, marked: map[string]time.Duration{}}
+			s := &SQS{config: &SQSConf{
+				Queue:            "queue",
+				DeleteStrategy:   DeleteStrategyOnSuccess,
+				DedupWindow:      time.Hour,
+				IdempotencyStore: store,
+			}}
+
+			consumed := 0
+			consumeFn := func(_ context.Context, data []byte, attributes map[string]types.MessageAttributeValue) error {
+				consumed++
+				return nil
+			}
+
+			toDelete, _ := s.consumeMessages(context.Background(), &worker{}, getQueueContent().Messages, s.consumeEach(consumeFn))
+			assert.Len(t, toDelete, 3)
+			assert.Equal(t, tt.wantConsumed, consumed)
+			assert.Len(t, store.marked, tt.wantConsumed)
+			assert.Equal(t, time.Hour, store.marked["msg1"])
+		})
+	}
+}
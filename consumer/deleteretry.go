@@ -0,0 +1,77 @@
+package consumer
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// deleteRetries holds the messages whose delete was throttled until they are deleted again.
+type deleteRetries struct {
+	mu        sync.Mutex
+	messages  []types.Message
+	throttled int
+	next      time.Time
+}
+
+// throttledDelete reports whether the delete error is throttling and DeleteRetryBufferSize lets it be retried.
+func (s *SQS) throttledDelete(err error) bool {
+	return s.config.DeleteRetryBufferSize > 0 && DefaultReceiveErrorClassifier(err) == ReceiveErrorThrottled
+}
+
+// deferDeletes buffers the messages of a throttled delete for retryDeletes, dropping the ones exceeding
+// DeleteRetryBufferSize, and backs off the next retry.
+func (s *SQS) deferDeletes(msgs []types.Message) {
+	r := &s.deleteRetries
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if room := max(0, s.config.DeleteRetryBufferSize-len(r.messages)); room < len(msgs) {
+		s.logger().Warn("delete retry buffer full, dropping throttled deletes, the messages will be redelivered",
+			slog.Int("dropped", len(msgs)-room))
+		msgs = msgs[:room]
+	}
+
+	r.messages = append(r.messages, msgs...)
+	r.throttled++
+	r.next = s.now().Add(s.throttleDelay(r.throttled))
+}
+
+// retryDeletes deletes the buffered messages once their backoff elapsed. Deletes throttled again go back to the
+// buffer with a longer backoff.
+func (s *SQS) retryDeletes(ctx context.Context) error {
+	r := &s.deleteRetries
+	r.mu.Lock()
+	if len(r.messages) == 0 || s.now().Before(r.next) {
+		r.mu.Unlock()
+		return nil
+	}
+	msgs := r.messages
+	r.messages = nil
+	throttled := r.throttled
+	r.mu.Unlock()
+
+	if err := s.deleteSqsMessages(ctx, msgs); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	if r.throttled == throttled {
+		r.throttled = 0
+	}
+	r.mu.Unlock()
+	return nil
+}
+
+// takeDeleteRetries empties the buffer for Flush.
+func (s *SQS) takeDeleteRetries() []types.Message {
+	r := &s.deleteRetries
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	msgs := r.messages
+	r.messages = nil
+	return msgs
+}
@@ -0,0 +1,67 @@
+package consumer
+
+import (
+	"bytes"
+	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestSQS_DeleteRetryBufferSize(t *testing.T) {
+	sqsMock := new(SqsMock)
+	sqsMock.On("DeleteMessageBatch", mock.Anything, mock.AnythingOfType("*sqs.DeleteMessageBatchInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, &types.OverLimit{}).Twice()
+	sqsMock.On("DeleteMessageBatch", mock.Anything, mock.AnythingOfType("*sqs.DeleteMessageBatchInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+
+	logs := &bytes.Buffer{}
+	clk := &fakeClock{now: time.Unix(1700000000, 0)}
+	s := &SQS{sqs: sqsMock, clock: clk, config: &SQSConf{
+		Queue:                 "queue",
+		DeleteRetryBufferSize: 2,
+		ThrottleBackoff:       time.Second,
+		MaxThrottleBackoff:    time.Minute,
+		Logger:                slog.New(slog.NewTextHandler(logs, nil)),
+	}}
+
+	require.NoError(t, s.deleteSqsMessages(context.Background(), getQueueContent().Messages))
+	assert.Contains(t, logs.String(), "dropped=1")
+
+	require.NoError(t, s.retryDeletes(context.Background()))
+	assert.Len(t, sqsMock.deleteInputs, 1, "the retry waits for the backoff")
+
+	clk.Sleep(context.Background(), time.Second)
+	require.NoError(t, s.retryDeletes(context.Background()))
+	assert.Len(t, sqsMock.deleteInputs, 2, "the retry is throttled again")
+
+	clk.Sleep(context.Background(), time.Second)
+	require.NoError(t, s.retryDeletes(context.Background()))
+	assert.Len(t, sqsMock.deleteInputs, 2, "the second retry waits for the doubled backoff")
+
+	clk.Sleep(context.Background(), time.Second)
+	require.NoError(t, s.retryDeletes(context.Background()))
+	require.Len(t, sqsMock.deleteInputs, 3)
+
+	ids := make([]string, 0, 2)
+	for _, entry := range sqsMock.deleteInputs[2].Entries {
+		ids = append(ids, aws.ToString(entry.Id))
+	}
+	assert.Equal(t, []string{"msg1", "msg2"}, ids)
+	assert.Equal(t, int64(2), s.Stats().DeletedTotal)
+	assert.Empty(t, s.takeDeleteRetries())
+}
+
+func TestSQS_deleteThrottledWithoutRetryBuffer(t *testing.T) {
+	sqsMock := new(SqsMock)
+	sqsMock.On("DeleteMessageBatch", mock.Anything, mock.AnythingOfType("*sqs.DeleteMessageBatchInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, &types.OverLimit{})
+
+	s := &SQS{sqs: sqsMock, config: &SQSConf{Queue: "queue"}}
+	assert.ErrorAs(t, s.deleteSqsMessages(context.Background(), getQueueContent().Messages), new(*types.OverLimit))
+}
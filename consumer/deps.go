@@ -0,0 +1,14 @@
+package consumer
+
+import (
+	"context"
+)
+
+type depsKey struct{}
+
+// DepsFromContext returns the HandlerDeps of the consumer a ContextConsumerFn is called by,
+// false when they are not set or not a T.
+func DepsFromContext[T any](ctx context.Context) (T, bool) {
+	deps, ok := ctx.Value(depsKey{}).(T)
+	return deps, ok
+}
@@ -0,0 +1,33 @@
+package consumer
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDepsFromContext(t *testing.T) {
+	type deps struct {
+		prefix string
+	}
+
+	s := &SQS{config: &SQSConf{Queue: "queue", HandlerDeps: &deps{prefix: "order-"}}}
+
+	var got []string
+	consumeFn := func(ctx context.Context, data []byte, attributes map[string]types.MessageAttributeValue) error {
+		d, ok := DepsFromContext[*deps](ctx)
+		assert.True(t, ok)
+		got = append(got, d.prefix+string(data))
+
+		_, ok = DepsFromContext[string](ctx)
+		assert.False(t, ok)
+		return nil
+	}
+
+	s.consumeMessages(context.Background(), &worker{}, getQueueContent().Messages, s.consumeEach(consumeFn))
+	assert.Equal(t, []string{"order-msg1", "order-msg2", "order-msg3"}, got)
+
+	_, ok := DepsFromContext[*deps](context.Background())
+	assert.False(t, ok)
+}
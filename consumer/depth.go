@@ -0,0 +1,109 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"log/slog"
+	"strconv"
+	"time"
+)
+
+// drainPollInterval is the delay between two queue depth checks of WaitForEmpty.
+const drainPollInterval = time.Second
+
+// WaitForEmpty polls the queue depth, counting the visible, in flight and delayed messages, until it reaches zero.
+// It is meant for integration tests asserting that every published message was consumed. Once timeout elapsed,
+// it returns a SentinelErrorQueueNotEmpty error holding the last count read.
+func (s *SQS) WaitForEmpty(ctx context.Context, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	remaining := -1
+	for {
+		depth, err := s.queueDepth(ctx)
+		if err != nil && ctx.Err() == nil {
+			return err
+		}
+		if err == nil {
+			if depth == 0 {
+				return nil
+			}
+			remaining = depth
+		}
+
+		s.sleep(ctx, drainPollInterval)
+		if ctx.Err() != nil {
+			if remaining < 0 {
+				return fmt.Errorf("%w: queue depth unknown: %w", SentinelErrorQueueNotEmpty, ctx.Err())
+			}
+			return fmt.Errorf("%w: %d messages remaining", SentinelErrorQueueNotEmpty, remaining)
+		}
+	}
+}
+
+// QueueDepthRecorder is implemented by the MetricsRecorder also recording the depth of the queue, the visible, in
+// flight and delayed messages, read every QueueDepthInterval.
+type QueueDepthRecorder interface {
+	QueueDepth(queue string, depth int)
+}
+
+// pollQueueDepth records the queue depth every QueueDepthInterval until ctx is done.
+func (s *SQS) pollQueueDepth(ctx context.Context) {
+	r, ok := s.metrics().(QueueDepthRecorder)
+	if !ok {
+		return
+	}
+
+	for {
+		depth, err := s.queueDepth(ctx)
+		switch {
+		case err == nil:
+			r.QueueDepth(s.queueName(), depth)
+		case ctx.Err() == nil:
+			s.logger().Warn("error reading the queue depth", slog.Any("error", err.Error()), requestIDAttr(err))
+		}
+
+		s.sleep(ctx, s.config.QueueDepthInterval)
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// queueDepth returns the approximate number of visible, in flight and delayed messages of the queue.
+func (s *SQS) queueDepth(ctx context.Context) (int, error) {
+	counts, err := s.queueCounts(ctx,
+		types.QueueAttributeNameApproximateNumberOfMessages,
+		types.QueueAttributeNameApproximateNumberOfMessagesNotVisible,
+		types.QueueAttributeNameApproximateNumberOfMessagesDelayed,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	depth := 0
+	for _, n := range counts {
+		depth += n
+	}
+	return depth, nil
+}
+
+// queueCounts reads the named approximate message counts of the queue.
+func (s *SQS) queueCounts(ctx context.Context, names ...types.QueueAttributeName) (map[types.QueueAttributeName]int, error) {
+	out, err := s.sqs.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       aws.String(s.config.Queue),
+		AttributeNames: names,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[types.QueueAttributeName]int, len(names))
+	for _, name := range names {
+		counts[name], _ = strconv.Atoi(out.Attributes[string(name)])
+	}
+	return counts, nil
+}
@@ -0,0 +1,105 @@
+package consumer
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func TestSQS_WaitForEmpty(t *testing.T) {
+	tests := []struct {
+		name       string
+		attributes map[string]string
+		wantErr    string
+	}{
+		{
+			name:       "shouldReturnOnceEmpty",
+			attributes: map[string]string{"ApproximateNumberOfMessages": "0", "ApproximateNumberOfMessagesNotVisible": "0"},
+		},
+		{
+			name: "shouldReturnRemainingOnTimeout",
+			attributes: map[string]string{
+				"ApproximateNumberOfMessages":           "2",
+				"ApproximateNumberOfMessagesNotVisible": "1",
+				"ApproximateNumberOfMessagesDelayed":    "1",
+			},
+			wantErr: "queue not empty: 4 messages remaining",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqsMock := &SqsMock{queueAttributes: tt.attributes}
+			sqsMock.On("GetQueueAttributes", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+
+			s := &SQS{sqs: sqsMock, config: &SQSConf{Queue: "queue"}}
+
+			err := s.WaitForEmpty(context.Background(), 50*time.Millisecond)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, SentinelErrorQueueNotEmpty)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+// slowDepthMock reads the queue attributes once, every following call lasting until ctx is done.
+type slowDepthMock struct {
+	*SqsMock
+	calls int
+}
+
+func (m *slowDepthMock) GetQueueAttributes(ctx context.Context, params *sqs.GetQueueAttributesInput, optFns ...func(*sqs.Options)) (*sqs.GetQueueAttributesOutput, error) {
+	m.calls++
+	if m.calls == 1 {
+		return m.SqsMock.GetQueueAttributes(ctx, params, optFns...)
+	}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestSQS_WaitForEmptyDeadlineDuringRead(t *testing.T) {
+	sqsMock := &SqsMock{queueAttributes: map[string]string{"ApproximateNumberOfMessages": "3"}}
+	sqsMock.On("GetQueueAttributes", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+
+	s := &SQS{sqs: &slowDepthMock{SqsMock: sqsMock}, config: &SQSConf{Queue: "queue"}, clock: &fakeClock{now: time.Now()}}
+
+	err := s.WaitForEmpty(context.Background(), 50*time.Millisecond)
+	assert.EqualError(t, err, "queue not empty: 3 messages remaining")
+}
+
+type depthRecorder struct {
+	fakeRecorder
+	depths []int
+	done   func()
+}
+
+func (r *depthRecorder) QueueDepth(_ string, depth int) {
+	r.depths = append(r.depths, depth)
+	if len(r.depths) == 2 {
+		r.done()
+	}
+}
+
+func TestSQS_pollQueueDepth(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sqsMock := &SqsMock{queueAttributes: map[string]string{
+		"ApproximateNumberOfMessages":           "2",
+		"ApproximateNumberOfMessagesNotVisible": "1",
+	}}
+	sqsMock.On("GetQueueAttributes", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+
+	recorder := &depthRecorder{done: cancel}
+	s := &SQS{sqs: sqsMock, clock: &fakeClock{now: time.Unix(1700000000, 0)},
+		config: &SQSConf{Queue: "queue", Metrics: recorder, QueueDepthInterval: time.Minute}}
+
+	s.pollQueueDepth(ctx)
+	assert.Equal(t, []int{3, 3}, recorder.depths)
+}
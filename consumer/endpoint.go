@@ -0,0 +1,59 @@
+package consumer
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+)
+
+// clientSettings returns the names of the set fields configuring the SQS client instead of the consumer, which a
+// Manager sharing its client cannot apply.
+func (c *SQSConf) clientSettings() []string {
+	var set []string
+	if c == nil {
+		return set
+	}
+
+	for _, f := range []struct {
+		name string
+		on   bool
+	}{
+		{"CredentialsProvider", c.CredentialsProvider != nil},
+		{"ContainerCredentials", c.ContainerCredentials},
+		{"EndpointURL", c.EndpointURL != ""},
+		{"EndpointResolver", c.EndpointResolver != nil},
+		{"APIOptions", len(c.APIOptions) > 0},
+	} {
+		if f.on {
+			set = append(set, f.name)
+		}
+	}
+	return set
+}
+
+// clientOptions returns the options of the SQS client created by NewSQSConsumer and NewSQSConsumerWithAWSConfig.
+func (c *SQSConf) clientOptions() []func(*sqs.Options) {
+	var opts []func(*sqs.Options)
+	if c == nil {
+		return opts
+	}
+
+	if c.EndpointURL != "" {
+		opts = append(opts, func(o *sqs.Options) {
+			o.BaseEndpoint = aws.String(c.EndpointURL)
+		})
+	}
+
+	if c.EndpointResolver != nil {
+		opts = append(opts, func(o *sqs.Options) {
+			o.EndpointResolverV2 = c.EndpointResolver
+		})
+	}
+
+	if len(c.APIOptions) > 0 {
+		opts = append(opts, func(o *sqs.Options) {
+			o.APIOptions = append(o.APIOptions, c.APIOptions...)
+		})
+	}
+
+	return opts
+}
@@ -0,0 +1,30 @@
+package consumer
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSQSConf_clientOptions(t *testing.T) {
+	resolver := sqs.NewDefaultEndpointResolverV2()
+	header := func(stack *middleware.Stack) error {
+		return smithyhttp.AddHeaderValue("X-Team", "payments")(stack)
+	}
+	conf := &SQSConf{EndpointURL: "http://localhost:4566", EndpointResolver: resolver,
+		APIOptions: []func(*middleware.Stack) error{header}}
+
+	o := sqs.Options{APIOptions: []func(*middleware.Stack) error{smithyhttp.AddHeaderValue("X-Config", "1")}}
+	for _, opt := range conf.clientOptions() {
+		opt(&o)
+	}
+	assert.Equal(t, "http://localhost:4566", aws.ToString(o.BaseEndpoint))
+	assert.Equal(t, resolver, o.EndpointResolverV2)
+	assert.Len(t, o.APIOptions, 2, "the aws.Config middlewares are kept")
+
+	assert.Empty(t, (&SQSConf{}).clientOptions())
+	assert.Empty(t, (*SQSConf)(nil).clientOptions())
+}
@@ -0,0 +1,73 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"log/slog"
+)
+
+const (
+	// ErrorRetryable leaves the message for redelivery.
+	ErrorRetryable = ErrorKind(iota)
+	// ErrorTerminal marks the message as poison: it is sent to DeadLetterQueueURL when set, and deleted.
+	ErrorTerminal
+	// ErrorIgnore deletes the message as if it was consumed.
+	ErrorIgnore
+)
+
+// ErrorKind is what happens to a message whose consumer function returned an error.
+type ErrorKind int
+
+// ErrorClassifier decides what happens to a message given the error its consumer function returned.
+// Custom classifiers can fall back to DefaultErrorClassifier for the errors they don't handle.
+type ErrorClassifier func(err error) ErrorKind
+
+// DefaultErrorClassifier treats every error as retryable.
+func DefaultErrorClassifier(error) ErrorKind {
+	return ErrorRetryable
+}
+
+// classifyError returns the ErrorKind of a consumer function error, ErrorTerminal for a DecodeError, which is
+// handled according to DecodeErrorAction.
+func (s *SQS) classifyError(err error) ErrorKind {
+	var decodeErr *DecodeError
+	switch {
+	case err == nil:
+		return ErrorRetryable
+	case errors.As(err, &decodeErr):
+		return ErrorTerminal
+	case s.config.ErrorClassifier == nil:
+		return DefaultErrorClassifier(err)
+	default:
+		return s.config.ErrorClassifier(err)
+	}
+}
+
+// retryLater makes a message that failed with an ErrorRetryable error visible again after the delay
+// ErrorVisibilityTimeout returns for err.
+func (s *SQS) retryLater(ctx context.Context, msg types.Message, err error) {
+	if s.config.ErrorVisibilityTimeout == nil {
+		return
+	}
+	if d := s.config.ErrorVisibilityTimeout(err); d > 0 {
+		s.applyResult(ctx, msg, Result{RequeueAfter: d})
+	}
+}
+
+// terminalFailed sends a message that failed with an ErrorTerminal error to the dead letter queue, when set, and
+// reports whether it must be deleted. A DecodeError is handled according to DecodeErrorAction instead.
+func (s *SQS) terminalFailed(ctx context.Context, msg types.Message, err error) bool {
+	var decodeErr *DecodeError
+	if errors.As(err, &decodeErr) {
+		return s.decodeFailed(ctx, msg, err)
+	}
+
+	if s.config.DeadLetterQueueURL != "" {
+		return s.applyResult(ctx, msg, Result{ToDLQ: true})
+	}
+
+	s.logger().Warn("deleting message that failed with a terminal error", slog.String("messageId", aws.ToString(msg.MessageId)))
+	return true
+}
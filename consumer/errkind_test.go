@@ -0,0 +1,123 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func TestSQS_ErrorClassifier(t *testing.T) {
+	errTemporary := errors.New("temporary")
+	errInvalid := errors.New("invalid")
+	errDuplicate := errors.New("duplicate")
+
+	classifier := func(err error) ErrorKind {
+		switch {
+		case errors.Is(err, errInvalid):
+			return ErrorTerminal
+		case errors.Is(err, errDuplicate):
+			return ErrorIgnore
+		default:
+			return DefaultErrorClassifier(err)
+		}
+	}
+
+	tests := []struct {
+		name          string
+		classifier    ErrorClassifier
+		dlq           string
+		wantDelete    []string
+		wantDLQ       int
+		wantProcessed int64
+		wantFailed    int64
+	}{
+		{name: "shouldRedeliverByDefault", wantFailed: 3},
+		{name: "shouldDeleteTerminalAndIgnored", classifier: classifier, wantDelete: []string{"msg2", "msg3"},
+			wantProcessed: 1, wantFailed: 2},
+		{name: "shouldDeadLetterTerminal", classifier: classifier, dlq: "dlq", wantDelete: []string{"msg2", "msg3"},
+			wantDLQ: 1, wantProcessed: 1, wantFailed: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqsMock := new(SqsMock)
+			sqsMock.On("SendMessage", mock.Anything, mock.AnythingOfType("*sqs.SendMessageInput"),
+				mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+
+			s := &SQS{sqs: sqsMock, config: &SQSConf{
+				Queue:              "queue",
+				DeleteStrategy:     DeleteStrategyOnSuccess,
+				DeadLetterQueueURL: tt.dlq,
+				ErrorClassifier:    tt.classifier,
+			}}
+
+			consumeFn := func(_ context.Context, data []byte, _ map[string]types.MessageAttributeValue) error {
+				return map[string]error{"msg1": errTemporary, "msg2": errInvalid, "msg3": errDuplicate}[string(data)]
+			}
+
+			toDelete, _ := s.consumeMessages(context.Background(), &worker{}, getQueueContent().Messages, s.consumeEach(consumeFn))
+
+			var ids []string
+			for _, msg := range toDelete {
+				ids = append(ids, *msg.MessageId)
+			}
+			assert.Equal(t, tt.wantDelete, ids)
+			assert.Len(t, sqsMock.sendInputs, tt.wantDLQ)
+
+			st := s.Stats()
+			assert.Equal(t, tt.wantProcessed, st.ProcessedTotal)
+			assert.Equal(t, tt.wantFailed, st.FailedTotal)
+		})
+	}
+}
+
+func TestSQS_classifyErrorKeepsDecodeErrorAction(t *testing.T) {
+	s := &SQS{config: &SQSConf{Queue: "queue", ErrorClassifier: func(error) ErrorKind {
+		return ErrorIgnore
+	}}}
+
+	assert.Equal(t, ErrorTerminal, s.classifyError(&DecodeError{Err: errors.New("not json")}))
+	assert.Equal(t, ErrorIgnore, s.classifyError(errors.New("other")))
+}
+
+func TestSQS_ErrorVisibilityTimeout(t *testing.T) {
+	errLocked := errors.New("locked")
+	errRateLimited := errors.New("rate limited")
+
+	sqsMock := new(SqsMock)
+	sqsMock.On("ChangeMessageVisibility", mock.Anything, mock.AnythingOfType("*sqs.ChangeMessageVisibilityInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+
+	s := &SQS{sqs: sqsMock, config: &SQSConf{
+		Queue:          "queue",
+		DeleteStrategy: DeleteStrategyOnSuccess,
+		ErrorVisibilityTimeout: func(err error) time.Duration {
+			switch {
+			case errors.Is(err, errLocked):
+				return 1500 * time.Millisecond
+			case errors.Is(err, errRateLimited):
+				return 24 * time.Hour
+			default:
+				return 0
+			}
+		},
+	}}
+
+	consumeFn := func(_ context.Context, data []byte, _ map[string]types.MessageAttributeValue) error {
+		return map[string]error{"msg1": errLocked, "msg2": errRateLimited, "msg3": errors.New("other")}[string(data)]
+	}
+
+	toDelete, _ := s.consumeMessages(context.Background(), &worker{}, getQueueContent().Messages, s.consumeEach(consumeFn))
+	assert.Empty(t, toDelete)
+
+	timeouts := map[string]int32{}
+	for _, input := range sqsMock.visibilityInputs {
+		timeouts[aws.ToString(input.ReceiptHandle)] = input.VisibilityTimeout
+	}
+	assert.Equal(t, map[string]int32{"handle1": 2, "handle2": 43200}, timeouts)
+}
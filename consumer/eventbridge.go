@@ -0,0 +1,42 @@
+package consumer
+
+import (
+	"encoding/json"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"maps"
+)
+
+const (
+	// EventBridgeDetailTypeAttribute is the message attribute holding the detail-type of an unwrapped EventBridge event.
+	EventBridgeDetailTypeAttribute = "EventBridge.DetailType"
+	// EventBridgeSourceAttribute is the message attribute holding the source of an unwrapped EventBridge event.
+	EventBridgeSourceAttribute = "EventBridge.Source"
+)
+
+// eventBridgeEnvelope is the event an EventBridge rule delivers to its SQS targets.
+type eventBridgeEnvelope struct {
+	DetailType *string         `json:"detail-type"`
+	Source     *string         `json:"source"`
+	Detail     json.RawMessage `json:"detail"`
+}
+
+// unwrapEventBridge replaces the body of a message holding an EventBridge event with the event detail. Bodies
+// missing one of detail-type, source and detail, like SNS notifications, are not EventBridge events.
+func unwrapEventBridge(msg *types.Message) {
+	var envelope eventBridgeEnvelope
+	if err := json.Unmarshal([]byte(aws.ToString(msg.Body)), &envelope); err != nil {
+		return
+	}
+	if envelope.DetailType == nil || envelope.Source == nil || len(envelope.Detail) == 0 {
+		return
+	}
+
+	msg.Body = aws.String(string(envelope.Detail))
+	msg.MessageAttributes = maps.Clone(msg.MessageAttributes)
+	if msg.MessageAttributes == nil {
+		msg.MessageAttributes = make(map[string]types.MessageAttributeValue, 2)
+	}
+	msg.MessageAttributes[EventBridgeDetailTypeAttribute] = StringAttr(*envelope.DetailType)
+	msg.MessageAttributes[EventBridgeSourceAttribute] = StringAttr(*envelope.Source)
+}
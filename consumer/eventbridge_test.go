@@ -0,0 +1,51 @@
+package consumer
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSQS_consumeMessagesUnwrapEventBridge(t *testing.T) {
+	event := `{"version":"0","id":"1","detail-type":"OrderPlaced","source":"shop","time":"2024-01-02T03:04:05Z",` +
+		`"detail":{"id":"order1"}}`
+	notification := `{"Type":"Notification","MessageId":"2","TopicArn":"arn","Message":"{}"}`
+
+	tests := []struct {
+		name           string
+		unwrap         bool
+		wantBodies     []string
+		wantDetailType string
+	}{
+		{name: "shouldUnwrapEventBridgeEvents", unwrap: true, wantBodies: []string{`{"id":"order1"}`, notification, "plain"}, wantDetailType: "OrderPlaced"},
+		{name: "shouldLeaveEventsByDefault", wantBodies: []string{event, notification, "plain"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &SQS{config: &SQSConf{Queue: "queue", DeleteStrategy: DeleteStrategyOnSuccess, UnwrapEventBridge: tt.unwrap}}
+
+			var bodies []string
+			var detailType string
+			consumeFn := func(_ context.Context, data []byte, attributes map[string]types.MessageAttributeValue) error {
+				bodies = append(bodies, string(data))
+				if attr, ok := attributes[EventBridgeDetailTypeAttribute]; ok {
+					detailType = aws.ToString(attr.StringValue)
+				}
+				return nil
+			}
+
+			messages := []types.Message{
+				{MessageId: aws.String("event"), Body: aws.String(event)},
+				{MessageId: aws.String("notification"), Body: aws.String(notification)},
+				{MessageId: aws.String("plain"), Body: aws.String("plain")},
+			}
+			s.consumeMessages(context.Background(), &worker{}, messages, s.consumeEach(consumeFn))
+
+			assert.Equal(t, tt.wantBodies, bodies)
+			assert.Equal(t, tt.wantDetailType, detailType)
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"io"
+	"maps"
+)
+
+const (
+	extendedPayloadSizeAttribute       = "ExtendedPayloadSize"
+	legacyExtendedPayloadSizeAttribute = "SQSLargePayloadSize"
+	s3PointerClass                     = "software.amazon.payloadoffloading.PayloadS3Pointer"
+)
+
+// S3Client fetches the payloads offloaded by the SQS extended client library, *s3.Client implements it.
+type S3Client interface {
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+}
+
+type s3Pointer struct {
+	Bucket string `json:"s3BucketName"`
+	Key    string `json:"s3Key"`
+}
+
+// resolveExtendedPayload replaces the body of a message sent by the SQS extended client library with the
+// payload stored in S3. The S3 object is left in place once the message is deleted.
+func (s *SQS) resolveExtendedPayload(ctx context.Context, msg *types.Message) error {
+	attribute := extendedPayloadSizeAttribute
+	if _, ok := msg.MessageAttributes[attribute]; !ok {
+		attribute = legacyExtendedPayloadSizeAttribute
+		if _, ok := msg.MessageAttributes[attribute]; !ok {
+			return nil
+		}
+	}
+
+	pointer, err := parseS3Pointer(aws.ToString(msg.Body))
+	if err != nil {
+		return err
+	}
+
+	out, err := s.config.S3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(pointer.Bucket),
+		Key:    aws.String(pointer.Key),
+	})
+	if err != nil {
+		return fmt.Errorf("fetching extended payload s3://%s/%s: %w", pointer.Bucket, pointer.Key, err)
+	}
+	defer out.Body.Close()
+
+	payload, err := io.ReadAll(out.Body)
+	if err != nil {
+		return fmt.Errorf("reading extended payload s3://%s/%s: %w", pointer.Bucket, pointer.Key, err)
+	}
+
+	msg.Body = aws.String(string(payload))
+	msg.MessageAttributes = maps.Clone(msg.MessageAttributes)
+	delete(msg.MessageAttributes, attribute)
+	return nil
+}
+
+// parseS3Pointer reads the ["software.amazon.payloadoffloading.PayloadS3Pointer", {...}] body format.
+func parseS3Pointer(body string) (s3Pointer, error) {
+	var pointer s3Pointer
+	var envelope []json.RawMessage
+	if err := json.Unmarshal([]byte(body), &envelope); err != nil || len(envelope) != 2 {
+		return pointer, SentinelErrorInvalidS3Pointer
+	}
+
+	var class string
+	if err := json.Unmarshal(envelope[0], &class); err != nil || class != s3PointerClass {
+		return pointer, SentinelErrorInvalidS3Pointer
+	}
+
+	if err := json.Unmarshal(envelope[1], &pointer); err != nil || pointer.Bucket == "" || pointer.Key == "" {
+		return pointer, SentinelErrorInvalidS3Pointer
+	}
+
+	return pointer, nil
+}
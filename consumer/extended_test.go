@@ -0,0 +1,69 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"strings"
+	"testing"
+)
+
+type s3Stub map[string]string
+
+func (s s3Stub) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	payload, ok := s[*params.Bucket+"/"+*params.Key]
+	if !ok {
+		return nil, errors.New("NoSuchKey")
+	}
+	return &s3.GetObjectOutput{Body: io.NopCloser(strings.NewReader(payload))}, nil
+}
+
+func TestSQS_resolveExtendedPayload(t *testing.T) {
+	pointer := `["software.amazon.payloadoffloading.PayloadS3Pointer",{"s3BucketName":"bucket","s3Key":"key"}]`
+	sizeAttr := map[string]types.MessageAttributeValue{
+		extendedPayloadSizeAttribute: {DataType: aws.String("Number"), StringValue: aws.String("7")},
+	}
+
+	tests := []struct {
+		name     string
+		msg      types.Message
+		wantBody string
+		wantErr  error
+	}{
+		{
+			name:     "shouldFetchPayload",
+			msg:      types.Message{Body: aws.String(pointer), MessageAttributes: sizeAttr},
+			wantBody: "payload",
+		},
+		{
+			name:     "shouldKeepRegularMessage",
+			msg:      types.Message{Body: aws.String(pointer)},
+			wantBody: pointer,
+		},
+		{
+			name:    "shouldErrorInvalidPointer",
+			msg:     types.Message{Body: aws.String(`{"s3Key":"key"}`), MessageAttributes: sizeAttr},
+			wantErr: SentinelErrorInvalidS3Pointer,
+		},
+	}
+
+	s := &SQS{config: &SQSConf{ExtendedClient: true, S3Client: s3Stub{"bucket/key": "payload"}}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.resolveExtendedPayload(context.Background(), &tt.msg)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantBody, *tt.msg.Body)
+			assert.NotContains(t, tt.msg.MessageAttributes, extendedPayloadSizeAttribute)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package consumer
+
+import (
+	"maps"
+	"sync"
+)
+
+// groupInFlight counts the messages of every group being consumed, for MaxInFlightPerGroup.
+type groupInFlight struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// reserve counts a message of the group in flight unless MaxInFlightPerGroup of them already are, and reports
+// whether it did.
+func (g *groupInFlight) reserve(key string, limit int) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.counts[key] >= limit {
+		return false
+	}
+	if g.counts == nil {
+		g.counts = make(map[string]int)
+	}
+	g.counts[key]++
+	return true
+}
+
+// release ends the reservations of keys.
+func (g *groupInFlight) release(keys []string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, key := range keys {
+		if g.counts[key]--; g.counts[key] <= 0 {
+			delete(g.counts, key)
+		}
+	}
+}
+
+// GroupInFlight returns the number of messages being consumed for every group having some, with
+// MaxInFlightPerGroup.
+func (s *SQS) GroupInFlight() map[string]int {
+	g := &s.groupInFlight
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return maps.Clone(g.counts)
+}
@@ -0,0 +1,61 @@
+package consumer
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func TestSQS_MaxInFlightPerGroup(t *testing.T) {
+	sqsMock := new(SqsMock)
+	sqsMock.On("ChangeMessageVisibilityBatch", mock.Anything, mock.AnythingOfType("*sqs.ChangeMessageVisibilityBatchInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+
+	s := &SQS{sqs: sqsMock, config: &SQSConf{
+		Queue:               "queue",
+		DeleteStrategy:      DeleteStrategyOnSuccess,
+		MaxInFlightPerGroup: 1,
+		GroupKeyExtractor: func(msg Message) string {
+			return aws.ToString(msg.MessageAttributes["user"].StringValue)
+		},
+	}}
+
+	user := func(id string) map[string]types.MessageAttributeValue {
+		return Attrs{"user": StringAttr(id)}
+	}
+	messages := []types.Message{
+		{MessageId: aws.String("msg1"), ReceiptHandle: aws.String("h1"), Body: aws.String("msg1"), MessageAttributes: user("alice")},
+		{MessageId: aws.String("msg2"), ReceiptHandle: aws.String("h2"), Body: aws.String("msg2"), MessageAttributes: user("alice")},
+		{MessageId: aws.String("msg3"), ReceiptHandle: aws.String("h3"), Body: aws.String("msg3"), MessageAttributes: user("bob")},
+	}
+
+	// bob already has a message in flight on another worker
+	assert.True(t, s.groupInFlight.reserve("bob", 1))
+
+	var consumed []string
+	var inFlight map[string]int
+	consumeFn := func(_ context.Context, data []byte, _ map[string]types.MessageAttributeValue) error {
+		consumed = append(consumed, string(data))
+		inFlight = s.GroupInFlight()
+		return nil
+	}
+
+	toDelete, n := s.consumeMessages(context.Background(), &worker{}, messages, s.consumeEach(consumeFn))
+
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []string{"msg1"}, consumed)
+	assert.Len(t, toDelete, 1)
+	assert.Equal(t, map[string]int{"alice": 1, "bob": 1}, inFlight)
+	assert.Equal(t, map[string]int{"bob": 1}, s.GroupInFlight())
+	assert.Equal(t, int64(2), s.Stats().GroupSaturatedTotal)
+
+	var released []string
+	for _, entry := range sqsMock.visibilityBatchInputs[0].Entries {
+		released = append(released, aws.ToString(entry.Id))
+		assert.Equal(t, int32(0), entry.VisibilityTimeout)
+	}
+	assert.Equal(t, []string{"msg2", "msg3"}, released)
+}
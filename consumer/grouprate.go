@@ -0,0 +1,75 @@
+package consumer
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"math"
+	"sync"
+	"time"
+)
+
+// groupRateSweepInterval is how often the limiters of the idle groups are evicted.
+const groupRateSweepInterval = time.Minute
+
+// groupRate holds the token bucket of every group for GroupRateLimit. The buckets refilled to their burst are
+// evicted, being the same as new ones.
+type groupRate struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	swept   time.Time
+}
+
+func (c *SQSConf) groupRateBurst() float64 {
+	if c.GroupRateBurst > 0 {
+		return float64(c.GroupRateBurst)
+	}
+	return math.Max(1, c.GroupRateLimit)
+}
+
+// reserve takes a token from the bucket of the group and returns how long to wait for it to be earned.
+func (g *groupRate) reserve(key string, now time.Time, rate, burst float64) time.Duration {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if now.Sub(g.swept) >= groupRateSweepInterval {
+		for k, b := range g.buckets {
+			if b.refill(now, rate, burst); b.tokens >= burst {
+				delete(g.buckets, k)
+			}
+		}
+		g.swept = now
+	}
+
+	b, ok := g.buckets[key]
+	if !ok {
+		if g.buckets == nil {
+			g.buckets = make(map[string]*tokenBucket)
+		}
+		b = &tokenBucket{}
+		g.buckets[key] = b
+	}
+
+	b.refill(now, rate, burst)
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / rate * float64(time.Second))
+}
+
+// paceGroup waits for the group of msg to be allowed another message by GroupRateLimit and reports whether it
+// can be consumed, false when ctx is done while waiting.
+func (s *SQS) paceGroup(ctx context.Context, msg types.Message) bool {
+	if s.config.GroupRateLimit == 0 {
+		return true
+	}
+
+	wait := s.groupRate.reserve(s.groupKey(msg), s.now(), s.config.GroupRateLimit, s.config.groupRateBurst())
+	if wait <= 0 {
+		return true
+	}
+
+	s.stats.groupRateLimited.Add(1)
+	s.sleep(ctx, wait)
+	return ctx.Err() == nil
+}
@@ -0,0 +1,46 @@
+package consumer
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestGroupRate_reserve(t *testing.T) {
+	var g groupRate
+	now := time.Unix(1700000000, 0)
+
+	assert.Equal(t, time.Duration(0), g.reserve("tenant1", now, 2, 2))
+	assert.Equal(t, time.Duration(0), g.reserve("tenant1", now, 2, 2))
+	assert.Equal(t, 500*time.Millisecond, g.reserve("tenant1", now, 2, 2))
+	assert.Equal(t, time.Second, g.reserve("tenant1", now, 2, 2), "every reservation waits for its own token")
+	assert.Equal(t, time.Duration(0), g.reserve("tenant2", now, 2, 2), "groups are paced independently")
+
+	// both buckets refilled by the sweep, the idle one is evicted
+	now = now.Add(groupRateSweepInterval)
+	assert.Equal(t, time.Duration(0), g.reserve("tenant1", now, 2, 2))
+	assert.Contains(t, g.buckets, "tenant1")
+	assert.NotContains(t, g.buckets, "tenant2")
+}
+
+func TestSQS_paceGroup(t *testing.T) {
+	clk := &fakeClock{now: time.Unix(1700000000, 0)}
+	s := &SQS{clock: clk, config: &SQSConf{Queue: "queue", GroupRateLimit: 1, GroupKeyExtractor: func(msg Message) string {
+		return aws.ToString(msg.Body)
+	}}}
+
+	msg := types.Message{MessageId: aws.String("msg1"), Body: aws.String("tenant1")}
+
+	started := s.now()
+	assert.True(t, s.paceGroup(context.Background(), msg))
+	assert.True(t, s.paceGroup(context.Background(), msg))
+	assert.Equal(t, time.Second, s.since(started))
+	assert.Equal(t, int64(1), s.Stats().GroupRateLimitedTotal)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.False(t, s.paceGroup(ctx, msg), "the message is left for redelivery once stopping")
+}
@@ -0,0 +1,66 @@
+package consumer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// contentEncodingGzip is the ContentEncodingAttribute value of the gzip compressed bodies.
+const contentEncodingGzip = "gzip"
+
+// gzipped reports whether the ContentEncodingAttribute of msg marks its body as gzip compressed.
+func (s *SQS) gzipped(msg types.Message) bool {
+	name := s.config.ContentEncodingAttribute
+	if name == "" {
+		name = DefaultContentEncodingAttribute
+	}
+
+	attr, ok := msg.MessageAttributes[name]
+	return ok && strings.EqualFold(aws.ToString(attr.StringValue), contentEncodingGzip)
+}
+
+// decompress replaces the base64 encoded, gzip compressed body of msg with its content. With MaxBodyBytes, the
+// content is read up to one byte past the limit, for the oversized check to skip the message.
+func (s *SQS) decompress(msg *types.Message) error {
+	compressed, err := base64.StdEncoding.DecodeString(aws.ToString(msg.Body))
+	if err != nil {
+		return err
+	}
+
+	r, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	var content io.Reader = r
+	if s.config.MaxBodyBytes > 0 {
+		content = io.LimitReader(r, int64(s.config.MaxBodyBytes)+1)
+	}
+
+	body, err := io.ReadAll(content)
+	if err != nil {
+		return err
+	}
+
+	msg.Body = aws.String(string(body))
+	return nil
+}
+
+// skipUndecompressable applies DecompressErrorAction, DecodeErrorAction by default, to a message whose body failed
+// to decompress and reports whether the message must be deleted.
+func (s *SQS) skipUndecompressable(ctx context.Context, msg types.Message, err error) bool {
+	action := s.config.DecompressErrorAction
+	if action == "" {
+		action = s.config.decodeErrorAction()
+	}
+
+	return s.skip(ctx, msg, action, "skipping message failing to decompress", slog.Any("error", err.Error()))
+}
@@ -0,0 +1,79 @@
+package consumer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func gzipBody(t *testing.T, content string) *string {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write([]byte(content))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	return aws.String(base64.StdEncoding.EncodeToString(buf.Bytes()))
+}
+
+func TestSQS_consumeMessagesDecompressBodies(t *testing.T) {
+	gzipAttr := Attrs{DefaultContentEncodingAttribute: StringAttr("GZIP")}
+	messages := []types.Message{
+		{MessageId: aws.String("gzip"), Body: gzipBody(t, "payload"), MessageAttributes: gzipAttr},
+		{MessageId: aws.String("plain"), Body: aws.String("plain payload")},
+		{MessageId: aws.String("invalid"), Body: aws.String("not gzip"), MessageAttributes: gzipAttr},
+		{MessageId: aws.String("bomb"), Body: gzipBody(t, strings.Repeat("a", 1000)), MessageAttributes: gzipAttr},
+	}
+
+	tests := []struct {
+		name         string
+		action       DecodeErrorAction
+		wantConsumed []string
+		wantDelete   []string
+	}{
+		{
+			name:         "shouldDeleteInvalidByDefault",
+			wantConsumed: []string{"payload", "plain payload"},
+			wantDelete:   []string{"invalid", "bomb", "gzip", "plain"},
+		},
+		{
+			name:         "shouldKeepInvalid",
+			action:       DecodeErrorKeep,
+			wantConsumed: []string{"payload", "plain payload"},
+			wantDelete:   []string{"bomb", "gzip", "plain"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &SQS{config: &SQSConf{
+				Queue:                 "queue",
+				DeleteStrategy:        DeleteStrategyOnSuccess,
+				DecompressBodies:      true,
+				DecompressErrorAction: tt.action,
+				MaxBodyBytes:          100,
+			}}
+
+			var consumed []string
+			consumeFn := func(_ context.Context, data []byte, _ map[string]types.MessageAttributeValue) error {
+				consumed = append(consumed, string(data))
+				return nil
+			}
+
+			toDelete, _ := s.consumeMessages(context.Background(), &worker{}, messages, s.consumeEach(consumeFn))
+
+			ids := make([]string, len(toDelete))
+			for i, msg := range toDelete {
+				ids[i] = aws.ToString(msg.MessageId)
+			}
+			assert.Equal(t, tt.wantConsumed, consumed)
+			assert.Equal(t, tt.wantDelete, ids)
+			assert.Equal(t, int64(1), s.Stats().OversizedTotal)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package consumer
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"sync"
+)
+
+// inProgress is the set of messages a worker is consuming, keyed by MessageId.
+type inProgress struct {
+	mu   sync.Mutex
+	msgs map[string]types.Message
+}
+
+func (p *inProgress) add(msgs []types.Message) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.msgs == nil {
+		p.msgs = make(map[string]types.Message, len(msgs))
+	}
+	for _, msg := range msgs {
+		// only what the visibility change requires, the bodies being released as they are consumed
+		p.msgs[aws.ToString(msg.MessageId)] = types.Message{MessageId: msg.MessageId, ReceiptHandle: msg.ReceiptHandle}
+	}
+}
+
+func (p *inProgress) remove(msg types.Message) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.msgs, aws.ToString(msg.MessageId))
+}
+
+func (p *inProgress) clear() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	clear(p.msgs)
+}
+
+func (p *inProgress) list() []types.Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	msgs := make([]types.Message, 0, len(p.msgs))
+	for _, msg := range p.msgs {
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
+
+// heartbeat reports whether the visibility heartbeat runs: the messages deleted on receipt need none.
+func (s *SQS) heartbeat(w *worker) bool {
+	return s.config.VisibilityHeartbeat > 0 && s.strategy(w) != DeleteStrategyImmediate
+}
+
+// beat extends the visibility timeout of the messages the worker is consuming every VisibilityHeartbeat, in
+// batches of ten, until ctx is done. Messages consumed between two beats are no longer extended.
+func (s *SQS) beat(ctx context.Context, w *worker) {
+	for {
+		s.sleep(ctx, s.config.VisibilityHeartbeat)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if msgs := w.inProgress.list(); len(msgs) > 0 {
+			s.extendVisibility(ctx, msgs, s.config.visibilityTimeout())
+		}
+	}
+}
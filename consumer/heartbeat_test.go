@@ -0,0 +1,70 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// beatMock cancels the heartbeat once the first beat extended every message in progress.
+type beatMock struct {
+	*SqsMock
+	cancel   context.CancelFunc
+	extended int
+	want     int
+}
+
+func (m *beatMock) ChangeMessageVisibilityBatch(ctx context.Context, params *sqs.ChangeMessageVisibilityBatchInput, optFns ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityBatchOutput, error) {
+	m.extended += len(params.Entries)
+	if m.extended >= m.want {
+		m.cancel()
+	}
+	return m.SqsMock.ChangeMessageVisibilityBatch(ctx, params, optFns...)
+}
+
+func TestSQS_beat(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sqsMock := new(SqsMock)
+	sqsMock.On("ChangeMessageVisibilityBatch", mock.Anything, mock.AnythingOfType("*sqs.ChangeMessageVisibilityBatchInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+
+	s := &SQS{sqs: &beatMock{SqsMock: sqsMock, cancel: cancel, want: 11}, config: &SQSConf{
+		Queue:               "queue",
+		DeleteStrategy:      DeleteStrategyOnSuccess,
+		VisibilityTimeout:   60,
+		VisibilityHeartbeat: time.Millisecond,
+	}}
+
+	msgs := make([]types.Message, 0)
+	for i := 0; i < 12; i++ {
+		id := fmt.Sprintf("msg%d", i)
+		msgs = append(msgs, types.Message{MessageId: aws.String(id), ReceiptHandle: aws.String(id), Body: aws.String("body")})
+	}
+
+	w := &worker{}
+	w.inProgress.add(msgs)
+	w.inProgress.remove(msgs[0])
+	s.beat(ctx, w)
+
+	require.Len(t, sqsMock.visibilityBatchInputs, 2)
+	assert.Len(t, sqsMock.visibilityBatchInputs[0].Entries, 10)
+	assert.Len(t, sqsMock.visibilityBatchInputs[1].Entries, 1)
+	for _, input := range sqsMock.visibilityBatchInputs {
+		for _, entry := range input.Entries {
+			assert.NotEqual(t, "msg0", aws.ToString(entry.Id))
+			assert.Equal(t, int32(60), entry.VisibilityTimeout)
+		}
+	}
+
+	w.inProgress.clear()
+	assert.Empty(t, w.inProgress.list())
+}
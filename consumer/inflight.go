@@ -0,0 +1,91 @@
+package consumer
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	// underDeliveryRatio is the share of the requested messages below which the receives of a window are
+	// considered under delivered.
+	underDeliveryRatio = 0.1
+
+	// standardInFlightLimit and fifoInFlightLimit are the SQS caps on the messages in flight.
+	standardInFlightLimit = 120000
+	fifoInFlightLimit     = 20000
+)
+
+// underDelivery counts the messages requested and received by every worker over an UnderDeliveryWindow.
+type underDelivery struct {
+	mu        sync.Mutex
+	start     time.Time
+	requested int
+	received  int
+}
+
+// observeDelivery records a receive for the UnderDeliveryWindow check, which runs on the receive closing a window.
+func (s *SQS) observeDelivery(ctx context.Context, requested, received int) {
+	if s.config.UnderDeliveryWindow == 0 {
+		return
+	}
+
+	u := &s.underDelivery
+	u.mu.Lock()
+	now := s.now()
+	if u.start.IsZero() {
+		u.start = now
+	}
+	u.requested += requested
+	u.received += received
+	if now.Sub(u.start) < s.config.UnderDeliveryWindow {
+		u.mu.Unlock()
+		return
+	}
+	requested, received = u.requested, u.received
+	u.start, u.requested, u.received = now, 0, 0
+	u.mu.Unlock()
+
+	if float64(received) >= underDeliveryRatio*float64(requested) {
+		return
+	}
+	s.checkInFlightLimit(ctx, requested, received)
+}
+
+// checkInFlightLimit warns when the receives of a window were under delivered while the queue had visible
+// messages, the symptom of a queue at its in-flight limit.
+func (s *SQS) checkInFlightLimit(ctx context.Context, requested, received int) {
+	counts, err := s.queueCounts(ctx,
+		types.QueueAttributeNameApproximateNumberOfMessages,
+		types.QueueAttributeNameApproximateNumberOfMessagesNotVisible,
+	)
+	if err != nil {
+		s.logger().Warn("error reading queue counts for the in-flight limit check", slog.Any("error", err.Error()), requestIDAttr(err))
+		return
+	}
+
+	visible := counts[types.QueueAttributeNameApproximateNumberOfMessages]
+	if visible < maxBatchSize {
+		return
+	}
+
+	limit := standardInFlightLimit
+	if s.config.FIFO {
+		limit = fifoInFlightLimit
+	}
+
+	s.stats.underDelivered.Add(1)
+	s.logger().Warn("receives return few messages while the queue has visible ones, it may be at its in-flight limit: "+
+		"lower VisibilityTimeout or delete the messages sooner",
+		slog.Int("requested", requested),
+		slog.Int("received", received),
+		slog.Int("visible", visible),
+		slog.Int("inFlight", counts[types.QueueAttributeNameApproximateNumberOfMessagesNotVisible]),
+		slog.Int("inFlightLimit", limit))
+
+	if s.config.OnUnderDelivery != nil {
+		s.config.OnUnderDelivery(requested, received)
+	}
+}
@@ -0,0 +1,56 @@
+package consumer
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestSQS_UnderDeliveryWindow(t *testing.T) {
+	tests := []struct {
+		name    string
+		visible string
+		want    int64
+	}{
+		{name: "shouldFlagVisibleMessagesNotDelivered", visible: "500", want: 1},
+		{name: "shouldNotFlagEmptyQueue", visible: "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqsMock := &receiveSequenceMock{SqsMock: &SqsMock{queueAttributes: map[string]string{
+				string(types.QueueAttributeNameApproximateNumberOfMessages):           tt.visible,
+				string(types.QueueAttributeNameApproximateNumberOfMessagesNotVisible): "120000",
+			}}}
+			sqsMock.On("GetQueueAttributes", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+
+			var calls [][2]int
+			s := &SQS{sqs: sqsMock, clock: &fakeClock{now: time.Unix(1700000000, 0)}, config: &SQSConf{
+				Queue:               "queue",
+				MaxNumberOfMessages: 10,
+				UnderDeliveryWindow: time.Second,
+				OnUnderDelivery: func(requested, received int) {
+					calls = append(calls, [2]int{requested, received})
+				},
+			}}
+
+			consumeFn := func(_ context.Context, _ []byte, _ map[string]types.MessageAttributeValue) error { return nil }
+			w := &worker{}
+			for range 2 {
+				_, err := s.pollCycle(context.Background(), s.consumeEach(consumeFn), w)
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, s.Stats().UnderDeliveredTotal)
+			if tt.want > 0 {
+				assert.Equal(t, [][2]int{{20, 0}}, calls)
+			} else {
+				assert.Empty(t, calls)
+			}
+		})
+	}
+}
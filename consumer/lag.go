@@ -0,0 +1,51 @@
+package consumer
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// OldestMessageAgeFunc reads the age of the oldest message of queue, the queue URL. SQS only publishes it as the
+// ApproximateAgeOfOldestMessage CloudWatch metric, GetQueueAttributes not returning it.
+type OldestMessageAgeFunc func(ctx context.Context, queue string) (time.Duration, error)
+
+// OldestMessageAgeRecorder is implemented by the MetricsRecorder also recording the age of the oldest message of
+// the queue, read every OldestMessageAgeInterval.
+type OldestMessageAgeRecorder interface {
+	OldestMessageAge(queue string, age time.Duration)
+}
+
+// OldestMessageAge returns the age of the oldest message of the queue last read with OldestMessageAgeInterval,
+// zero until it is first read.
+func (s *SQS) OldestMessageAge() time.Duration {
+	return time.Duration(s.oldestMessageAge.Load())
+}
+
+// pollOldestMessageAge reads the age of the oldest message every OldestMessageAgeInterval until ctx is done.
+func (s *SQS) pollOldestMessageAge(ctx context.Context) {
+	for {
+		s.readOldestMessageAge(ctx)
+
+		s.sleep(ctx, s.config.OldestMessageAgeInterval)
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+func (s *SQS) readOldestMessageAge(ctx context.Context) {
+	age, err := s.config.OldestMessageAge(ctx, s.config.Queue)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.logger().Warn("error reading the oldest message age", slog.Any("error", err.Error()))
+		}
+		return
+	}
+
+	age = max(0, age)
+	s.oldestMessageAge.Store(int64(age))
+	if r, ok := s.metrics().(OldestMessageAgeRecorder); ok {
+		r.OldestMessageAge(s.queueName(), age)
+	}
+}
@@ -0,0 +1,61 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+type ageRecorder struct {
+	fakeRecorder
+	mu   sync.Mutex
+	ages []time.Duration
+}
+
+func (r *ageRecorder) OldestMessageAge(queue string, age time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ages = append(r.ages, age)
+}
+
+func TestSQS_pollOldestMessageAge(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ages := []time.Duration{time.Minute, 0, 2 * time.Minute}
+	var queues []string
+	recorder := &ageRecorder{}
+	s := &SQS{
+		config: &SQSConf{
+			Queue:                    "https://sqs.eu-west-1.amazonaws.com/123456789012/orders",
+			Metrics:                  recorder,
+			OldestMessageAgeInterval: time.Minute,
+			OldestMessageAge: func(_ context.Context, queue string) (time.Duration, error) {
+				queues = append(queues, queue)
+				if len(ages) == 0 {
+					cancel()
+					return 0, ctx.Err()
+				}
+				if len(ages) == 2 {
+					ages = ages[1:]
+					return 0, errors.New("fake cloudwatch error")
+				}
+				age := ages[0]
+				ages = ages[1:]
+				return age, nil
+			},
+		},
+		clock: &fakeClock{now: time.Unix(1700000000, 0)},
+	}
+
+	assert.Zero(t, s.OldestMessageAge())
+	s.pollOldestMessageAge(ctx)
+
+	assert.Len(t, queues, 4)
+	assert.Equal(t, "https://sqs.eu-west-1.amazonaws.com/123456789012/orders", queues[0])
+	assert.Equal(t, []time.Duration{time.Minute, 2 * time.Minute}, recorder.ages)
+	assert.Equal(t, 2*time.Minute, s.OldestMessageAge())
+}
@@ -0,0 +1,175 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"golang.org/x/sync/errgroup"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Manager runs several consumers sharing a single SQS client. They start together and stop together: when one of
+// them returns an error, the others are stopped and Start returns that error.
+type Manager struct {
+	sqs SQSClient
+
+	mu        sync.Mutex
+	consumers []*SQS
+	starts    []func(ctx context.Context) error
+	stop      context.CancelFunc
+	stopped   chan struct{}
+}
+
+// NewManager creates the SQS client shared by the consumers of the manager from cfg and optFns. The client
+// settings of SQSConf, such as EndpointURL or APIOptions, are options of that client: the consumers registering
+// them are rejected, see clientSettings.
+func NewManager(cfg aws.Config, optFns ...func(*sqs.Options)) *Manager {
+	return newManager(sqs.NewFromConfig(cfg, optFns...))
+}
+
+func newManager(client SQSClient) *Manager {
+	return &Manager{sqs: client}
+}
+
+// Consume registers a consumer of conf running consumeFn, see SQS.StartWithContext.
+func (m *Manager) Consume(conf *SQSConf, consumeFn ContextConsumerFn) (*SQS, error) {
+	return m.add(conf, func(s *SQS, ctx context.Context) error {
+		return s.StartWithContext(ctx, consumeFn)
+	})
+}
+
+// ConsumeBatch registers a consumer of conf running consumeFn, see SQS.StartBatch.
+func (m *Manager) ConsumeBatch(conf *SQSConf, consumeFn BatchConsumerFn) (*SQS, error) {
+	return m.add(conf, func(s *SQS, ctx context.Context) error {
+		return s.StartBatch(ctx, consumeFn)
+	})
+}
+
+// ConsumeWithResult registers a consumer of conf running consumeFn, see SQS.StartWithResult.
+func (m *Manager) ConsumeWithResult(conf *SQSConf, consumeFn ResultConsumerFn) (*SQS, error) {
+	return m.add(conf, func(s *SQS, ctx context.Context) error {
+		return s.StartWithResult(ctx, consumeFn)
+	})
+}
+
+func (m *Manager) add(conf *SQSConf, start func(s *SQS, ctx context.Context) error) (*SQS, error) {
+	if settings := conf.clientSettings(); len(settings) > 0 {
+		return nil, fmt.Errorf("%w: set %s on the client of NewManager, not on its consumers",
+			SentinelErrorInvalidConfig, strings.Join(settings, ", "))
+	}
+
+	s, err := newSQS(m.sqs, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.consumers = append(m.consumers, s)
+	m.starts = append(m.starts, func(ctx context.Context) error {
+		return start(s, ctx)
+	})
+
+	return s, nil
+}
+
+// Start runs every registered consumer until ctx is done, Stop is called or one of them returns an error.
+func (m *Manager) Start(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stopped := make(chan struct{})
+	defer close(stopped)
+
+	m.mu.Lock()
+	m.stop = cancel
+	m.stopped = stopped
+	starts := m.starts
+	m.mu.Unlock()
+
+	g, ctx := errgroup.WithContext(ctx)
+	for _, start := range starts {
+		g.Go(func() error {
+			return start(ctx)
+		})
+	}
+
+	return g.Wait()
+}
+
+// Stop stops every consumer, see SQS.Stop: they stop receiving messages and consume the ones they received for up
+// to their DrainTimeout. The consumers still running after the longest DrainTimeout, including the ones which were
+// not started yet when Stop was called, are cancelled.
+func (m *Manager) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.stop == nil {
+		return
+	}
+
+	var drain time.Duration
+	for _, s := range m.consumers {
+		s.Stop()
+		drain = max(drain, s.config.DrainTimeout)
+	}
+
+	stop, stopped := m.stop, m.stopped
+	go func() {
+		select {
+		case <-time.After(drain):
+			stop()
+		case <-stopped:
+		}
+	}()
+}
+
+// Consumers returns the registered consumers, in registration order.
+func (m *Manager) Consumers() []*SQS {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]*SQS(nil), m.consumers...)
+}
+
+// Stats sums the counters of every consumer. Uptime is the longest one and CircuitState is OPEN when the circuit of
+// any consumer is open, HALF_OPEN when any is half open.
+func (m *Manager) Stats() Stats {
+	var total Stats
+	total.CircuitState = CircuitClosed
+
+	for _, s := range m.Consumers() {
+		st := s.Stats()
+		total.ReceivedTotal += st.ReceivedTotal
+		total.ProcessedTotal += st.ProcessedTotal
+		total.FailedTotal += st.FailedTotal
+		total.DeletedTotal += st.DeletedTotal
+		total.DeleteFailedTotal += st.DeleteFailedTotal
+		total.ExpiredTotal += st.ExpiredTotal
+		total.FilteredTotal += st.FilteredTotal
+		total.DuplicateTotal += st.DuplicateTotal
+		total.OversizedTotal += st.OversizedTotal
+		total.StaleTotal += st.StaleTotal
+		total.OtherShardTotal += st.OtherShardTotal
+		total.UnderDeliveredTotal += st.UnderDeliveredTotal
+		total.DeclinedTotal += st.DeclinedTotal
+		total.RetryBudgetTokens += st.RetryBudgetTokens
+		total.RetryBudgetExhaustedTotal += st.RetryBudgetExhaustedTotal
+		total.PollerBlockedTotal += st.PollerBlockedTotal
+		total.GroupSaturatedTotal += st.GroupSaturatedTotal
+		total.GroupRateLimitedTotal += st.GroupRateLimitedTotal
+		total.Uptime = max(total.Uptime, st.Uptime)
+
+		switch {
+		case st.CircuitState == CircuitOpen:
+			total.CircuitState = CircuitOpen
+		case st.CircuitState == CircuitHalfOpen && total.CircuitState != CircuitOpen:
+			total.CircuitState = CircuitHalfOpen
+		}
+	}
+
+	return total
+}
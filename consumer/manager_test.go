@@ -0,0 +1,111 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestManager(t *testing.T) {
+	sqsMock := new(SqsMock)
+	sqsMock.On("ReceiveMessage", mock.Anything, mock.AnythingOfType("*sqs.ReceiveMessageInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+	sqsMock.On("DeleteMessageBatch", mock.Anything, mock.AnythingOfType("*sqs.DeleteMessageBatchInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+
+	m := newManager(sqsMock)
+
+	orders, err := m.Consume(&SQSConf{Queue: "orders", Concurrency: 1}, func(_ context.Context, data []byte, attributes map[string]types.MessageAttributeValue) error {
+		return nil
+	})
+	require.NoError(t, err)
+
+	invoices, err := m.ConsumeBatch(&SQSConf{Queue: "invoices", Concurrency: 1}, func(msgs []Message) ([]string, error) {
+		return nil, nil
+	})
+	require.NoError(t, err)
+
+	_, err = m.Consume(&SQSConf{}, nil)
+	assert.ErrorIs(t, err, SentinelErrorQueueNotSet)
+	_, err = m.Consume(&SQSConf{Queue: "payments", EndpointURL: "http://localhost:4566"}, nil)
+	assert.ErrorIs(t, err, SentinelErrorInvalidConfig, "the client settings of a consumer are ignored by the shared client")
+	assert.Equal(t, []*SQS{orders, invoices}, m.Consumers())
+
+	done := make(chan error)
+	go func() {
+		done <- m.Start(context.Background())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, orders.WaitReady(ctx))
+	require.NoError(t, invoices.WaitReady(ctx))
+
+	m.Stop()
+	require.NoError(t, <-done)
+
+	stats := m.Stats()
+	assert.Equal(t, orders.Stats().ReceivedTotal+invoices.Stats().ReceivedTotal, stats.ReceivedTotal)
+	assert.GreaterOrEqual(t, stats.ReceivedTotal, int64(6))
+	assert.Equal(t, CircuitClosed, stats.CircuitState)
+}
+
+func TestManagerStopDrainsConsumers(t *testing.T) {
+	sqsMock := new(SqsMock)
+	sqsMock.On("ReceiveMessage", mock.Anything, mock.AnythingOfType("*sqs.ReceiveMessageInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+	sqsMock.On("DeleteMessageBatch", mock.Anything, mock.AnythingOfType("*sqs.DeleteMessageBatchInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+
+	m := newManager(sqsMock)
+
+	consuming := make(chan struct{}, 3)
+	release := make(chan struct{})
+	orders, err := m.Consume(&SQSConf{Queue: "orders", Concurrency: 1, DeleteStrategy: DeleteStrategyOnSuccess,
+		DrainTimeout: time.Minute}, func(ctx context.Context, data []byte, attributes map[string]types.MessageAttributeValue) error {
+		consuming <- struct{}{}
+		<-release
+		return ctx.Err()
+	})
+	require.NoError(t, err)
+
+	done := make(chan error)
+	go func() {
+		done <- m.Start(context.Background())
+	}()
+
+	<-consuming
+	m.Stop()
+	close(release)
+	require.NoError(t, <-done)
+
+	// the in-flight batch is consumed and deleted within DrainTimeout
+	assert.Equal(t, int64(3), orders.Stats().ProcessedTotal)
+	assert.Equal(t, int64(3), orders.Stats().DeletedTotal)
+}
+
+func TestManagerStopsOnError(t *testing.T) {
+	sqsMock := new(SqsMock)
+	sqsMock.On("ReceiveMessage", mock.Anything, mock.AnythingOfType("*sqs.ReceiveMessageInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+	sqsMock.On("DeleteMessageBatch", mock.Anything, mock.AnythingOfType("*sqs.DeleteMessageBatchInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+
+	m := newManager(sqsMock)
+	_, err := m.Consume(&SQSConf{Queue: "orders", Concurrency: 1}, func(_ context.Context, data []byte, attributes map[string]types.MessageAttributeValue) error {
+		return nil
+	})
+	require.NoError(t, err)
+
+	_, err = m.Consume(&SQSConf{Queue: "invoices", Concurrency: 1, PanicThreshold: 1}, func(_ context.Context, data []byte, attributes map[string]types.MessageAttributeValue) error {
+		panic(errors.New("fake panic"))
+	})
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, m.Start(context.Background()), SentinelErrorPanicStorm)
+}
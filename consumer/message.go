@@ -0,0 +1,78 @@
+package consumer
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"strconv"
+	"time"
+)
+
+// Message is a received SQS message along with its parsed system attributes. The attributes that were not
+// received, see SQSConf.SystemAttributeNames, are left to their zero value.
+type Message struct {
+	types.Message
+
+	SenderID                 string
+	SentTimestamp            time.Time
+	ApproximateReceiveCount  int
+	FirstReceiveTimestamp    time.Time
+	SequenceNumber           string
+	MessageGroupID           string
+	DeduplicationID          string
+	AWSTraceHeader           string
+	DeadLetterQueueSourceARN string
+
+	// QueueLatency is how long the message waited in the queue since it was sent, zero when the SentTimestamp
+	// was not received or is ahead of the local clock.
+	QueueLatency time.Duration
+}
+
+type messageKey struct{}
+
+func newMessage(msg types.Message, now time.Time) Message {
+	attr := func(name types.MessageSystemAttributeName) string {
+		return msg.Attributes[string(name)]
+	}
+
+	m := Message{
+		Message:                  msg,
+		SenderID:                 attr(types.MessageSystemAttributeNameSenderId),
+		SequenceNumber:           attr(types.MessageSystemAttributeNameSequenceNumber),
+		MessageGroupID:           attr(types.MessageSystemAttributeNameMessageGroupId),
+		DeduplicationID:          attr(types.MessageSystemAttributeNameMessageDeduplicationId),
+		AWSTraceHeader:           attr(types.MessageSystemAttributeNameAWSTraceHeader),
+		DeadLetterQueueSourceARN: attr(types.MessageSystemAttributeNameDeadLetterQueueSourceArn),
+	}
+
+	m.SentTimestamp, _ = sentTimestamp(msg)
+	if !m.SentTimestamp.IsZero() {
+		m.QueueLatency = max(0, now.Sub(m.SentTimestamp))
+	}
+	m.FirstReceiveTimestamp, _ = epochMillis(attr(types.MessageSystemAttributeNameApproximateFirstReceiveTimestamp))
+	m.ApproximateReceiveCount, _ = strconv.Atoi(attr(types.MessageSystemAttributeNameApproximateReceiveCount))
+
+	return m
+}
+
+func (s *SQS) message(msg types.Message) Message {
+	return newMessage(msg, s.now())
+}
+
+// MessageFromContext returns the message a ContextConsumerFn is called for.
+func MessageFromContext(ctx context.Context) (Message, bool) {
+	msg, ok := ctx.Value(messageKey{}).(Message)
+	return msg, ok
+}
+
+func sentTimestamp(msg types.Message) (time.Time, bool) {
+	return epochMillis(msg.Attributes[string(types.MessageSystemAttributeNameSentTimestamp)])
+}
+
+func epochMillis(v string) (time.Time, bool) {
+	ms, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return time.UnixMilli(ms), true
+}
@@ -0,0 +1,67 @@
+package consumer
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestNewMessage(t *testing.T) {
+	msg := newMessage(types.Message{
+		MessageId: aws.String("msg1"),
+		Attributes: map[string]string{
+			"SenderId":                         "AIDAEXAMPLE",
+			"SentTimestamp":                    "1700000000000",
+			"ApproximateFirstReceiveTimestamp": "1700000001000",
+			"ApproximateReceiveCount":          "3",
+			"SequenceNumber":                   "18849496460467696128",
+			"MessageGroupId":                   "group",
+			"MessageDeduplicationId":           "dedup",
+			"AWSTraceHeader":                   "Root=1-5759e988-bd862e3fe1be46a994272793",
+		},
+	}, time.UnixMilli(1700000005000))
+
+	assert.Equal(t, "msg1", *msg.MessageId)
+	assert.Equal(t, "AIDAEXAMPLE", msg.SenderID)
+	assert.Equal(t, time.UnixMilli(1700000000000), msg.SentTimestamp)
+	assert.Equal(t, time.UnixMilli(1700000001000), msg.FirstReceiveTimestamp)
+	assert.Equal(t, 3, msg.ApproximateReceiveCount)
+	assert.Equal(t, "18849496460467696128", msg.SequenceNumber)
+	assert.Equal(t, "group", msg.MessageGroupID)
+	assert.Equal(t, "dedup", msg.DeduplicationID)
+	assert.Equal(t, "Root=1-5759e988-bd862e3fe1be46a994272793", msg.AWSTraceHeader)
+	assert.Equal(t, 5*time.Second, msg.QueueLatency)
+
+	empty := newMessage(types.Message{}, time.Now())
+	assert.True(t, empty.SentTimestamp.IsZero())
+	assert.Zero(t, empty.ApproximateReceiveCount)
+	assert.Zero(t, empty.QueueLatency)
+
+	skewed := newMessage(types.Message{Attributes: map[string]string{
+		"SentTimestamp": strconv.FormatInt(time.Now().Add(time.Minute).UnixMilli(), 10),
+	}}, time.Now())
+	assert.Zero(t, skewed.QueueLatency)
+}
+
+func TestMessageFromContext(t *testing.T) {
+	s := &SQS{config: &SQSConf{Queue: "queue"}}
+
+	var got []string
+	consumeFn := func(ctx context.Context, data []byte, attributes map[string]types.MessageAttributeValue) error {
+		msg, ok := MessageFromContext(ctx)
+		require.True(t, ok)
+		got = append(got, *msg.MessageId)
+		return nil
+	}
+
+	s.consumeMessages(context.Background(), &worker{}, getQueueContent().Messages, s.consumeEach(consumeFn))
+	assert.Equal(t, []string{"msg1", "msg2", "msg3"}, got)
+
+	_, ok := MessageFromContext(context.Background())
+	assert.False(t, ok)
+}
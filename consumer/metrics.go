@@ -0,0 +1,37 @@
+package consumer
+
+import (
+	"strings"
+	"time"
+)
+
+// MetricsRecorder receives the consumer measurements, queue being the name of the consumed queue.
+// Implementations must be safe for concurrent use.
+type MetricsRecorder interface {
+	MessagesReceived(queue string, n int)
+	// QueueLatency is called for every message handed to the consumer function with how long it waited in the queue.
+	QueueLatency(queue string, latency time.Duration)
+	// MessagesProcessed is called after every consume function call with the number of messages it
+	// succeeded and failed to consume and how long it took.
+	MessagesProcessed(queue string, succeeded, failed int, elapsed time.Duration)
+	MessagesDeleted(queue string, n int)
+}
+
+type noopRecorder struct{}
+
+func (noopRecorder) MessagesReceived(string, int)                      {}
+func (noopRecorder) QueueLatency(string, time.Duration)                {}
+func (noopRecorder) MessagesProcessed(string, int, int, time.Duration) {}
+func (noopRecorder) MessagesDeleted(string, int)                       {}
+
+func (s *SQS) metrics() MetricsRecorder {
+	if s.config.Metrics == nil {
+		return noopRecorder{}
+	}
+	return s.config.Metrics
+}
+
+// queueName returns the last path segment of the queue URL.
+func (s *SQS) queueName() string {
+	return s.config.Queue[strings.LastIndex(s.config.Queue, "/")+1:]
+}
@@ -0,0 +1,54 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeRecorder struct {
+	mu        sync.Mutex
+	queues    []string
+	succeeded int
+	failed    int
+}
+
+func (r *fakeRecorder) MessagesReceived(queue string, n int) {}
+
+func (r *fakeRecorder) QueueLatency(queue string, latency time.Duration) {}
+
+func (r *fakeRecorder) MessagesProcessed(queue string, succeeded, failed int, elapsed time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.queues = append(r.queues, queue)
+	r.succeeded += succeeded
+	r.failed += failed
+}
+
+func (r *fakeRecorder) MessagesDeleted(queue string, n int) {}
+
+func TestSQS_Metrics(t *testing.T) {
+	recorder := &fakeRecorder{}
+	s := &SQS{config: &SQSConf{
+		Queue:          "https://sqs.eu-west-1.amazonaws.com/123456789012/orders",
+		DeleteStrategy: DeleteStrategyOnSuccess,
+		Metrics:        recorder,
+	}}
+
+	consumeFn := func(_ context.Context, data []byte, attributes map[string]types.MessageAttributeValue) error {
+		if string(data) == "msg2" {
+			return errors.New("fake consume error")
+		}
+		return nil
+	}
+
+	s.consumeMessages(context.Background(), &worker{}, getQueueContent().Messages, s.consumeEach(consumeFn))
+
+	assert.Equal(t, []string{"orders", "orders", "orders"}, recorder.queues)
+	assert.Equal(t, 2, recorder.succeeded)
+	assert.Equal(t, 1, recorder.failed)
+}
@@ -0,0 +1,9 @@
+package consumer
+
+// Chain decorates consumeFn with middlewares, the first middleware being the outermost one.
+func Chain(consumeFn ContextConsumerFn, middlewares ...Middleware) ContextConsumerFn {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		consumeFn = middlewares[i](consumeFn)
+	}
+	return consumeFn
+}
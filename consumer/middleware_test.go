@@ -0,0 +1,28 @@
+package consumer
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestChain(t *testing.T) {
+	var calls []string
+	trace := func(name string) Middleware {
+		return func(next ContextConsumerFn) ContextConsumerFn {
+			return func(ctx context.Context, data []byte, attributes map[string]types.MessageAttributeValue) error {
+				calls = append(calls, name)
+				return next(ctx, data, attributes)
+			}
+		}
+	}
+
+	consumeFn := Chain(func(ctx context.Context, data []byte, attributes map[string]types.MessageAttributeValue) error {
+		calls = append(calls, "consume")
+		return nil
+	}, trace("first"), trace("second"))
+
+	assert.NoError(t, consumeFn(context.Background(), []byte("msg1"), nil))
+	assert.Equal(t, []string{"first", "second", "consume"}, calls)
+}
@@ -3,44 +3,533 @@ package consumer
 import (
 	"context"
 	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/aws/smithy-go/middleware"
+	"golang.org/x/sync/semaphore"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 const (
-	DefaultMaxNumberOfMessages = int32(10)
-	DefaultWaitTimeSeconds     = int32(5)
-	DefaultConcurrency         = 5
+	DefaultMaxNumberOfMessages  = int32(10)
+	DefaultWaitTimeSeconds      = int32(5)
+	DefaultConcurrency          = 5
+	DefaultThrottleBackoff      = time.Second
+	DefaultMaxThrottleBackoff   = 30 * time.Second
+	DefaultTransientErrorDelay  = 200 * time.Millisecond
+	DefaultCircuitCooldown      = 30 * time.Second
+	DefaultPanicWindow          = time.Minute
+	DefaultStartupProbeAttempts = 3
+	DefaultStartupProbeBackoff  = time.Second
+	DefaultCloseTimeout         = 30 * time.Second
+	DefaultDrainTimeout         = 20 * time.Second
+	DefaultEmptyReceiveJitter   = 500 * time.Millisecond
+	DefaultClockSkewTolerance   = time.Second
+	DefaultWindowSize           = 100
+	DefaultWindowDuration       = 5 * time.Second
+	// DefaultContentEncodingAttribute is the message attribute marking the compressed bodies.
+	DefaultContentEncodingAttribute = "content-encoding"
+	// DefaultVisibilityDeadlineMargin leaves the consumer function time to return once its context is done.
+	DefaultVisibilityDeadlineMargin = 2 * time.Second
+	// DefaultRetryVisibilityFactor is the share of the visibility timeout in-process retries of a message can last.
+	DefaultRetryVisibilityFactor = 0.8
 
 	DeleteStrategyImmediate = DeleteStrategy("IMMEDIATE")
 	DeleteStrategyOnSuccess = DeleteStrategy("ON_SUCCESS")
+	// DeleteStrategyBatched buffers acknowledgements of successfully consumed messages and deletes them
+	// once a full SQS batch is pending, when Flush is called or when Start returns.
+	DeleteStrategyBatched = DeleteStrategy("BATCHED")
+	// DeleteStrategyNever leaves the deletion of the consumed messages to the consumer function, with the AckFunc
+	// of StartWithAck. The messages left out of consumption are deleted as with DeleteStrategyOnSuccess.
+	DeleteStrategyNever = DeleteStrategy("NEVER")
+
+	maxBatchSize = 10 // max batch size for SQS is 10
+
+	// emptyReceiveDelay is the wait after a receive without consumable messages, before EmptyReceiveJitter.
+	emptyReceiveDelay = time.Second
+
+	receiptHandleIsInvalid = "ReceiptHandleIsInvalid"
 )
 
 var (
-	SentinelErrorQueueNotSet = errors.New("queue not set")
-	SentinelErrorConfigIsNil = errors.New("configuration is nil")
-	SentinelErrorConfigAws   = errors.New("aws configuration error")
+	SentinelErrorQueueNotSet            = errors.New("queue not set")
+	SentinelErrorConfigIsNil            = errors.New("configuration is nil")
+	SentinelErrorConfigAws              = errors.New("aws configuration error")
+	SentinelErrorGroupIDNotSet          = errors.New("message group id not set for fifo queue")
+	SentinelErrorS3ClientNotSet         = errors.New("s3 client not set for extended client")
+	SentinelErrorInvalidS3Pointer       = errors.New("invalid extended client s3 pointer")
+	SentinelErrorHandlerPanic           = errors.New("consume function panicked")
+	SentinelErrorPanicStorm             = errors.New("consume function panicked repeatedly")
+	SentinelErrorDeadLetterQueueNotSet  = errors.New("dead letter queue not set")
+	SentinelErrorQueueDoesNotExist      = errors.New("queue does not exist")
+	SentinelErrorQueueNotEmpty          = errors.New("queue not empty")
+	SentinelErrorInvalidUnmarshalTarget = errors.New("unmarshal target is not a pointer to a struct")
+	SentinelErrorInvalidConfig          = errors.New("invalid configuration")
+	SentinelErrorNoRoute                = errors.New("no route for message type")
+	SentinelErrorOldestMessageAgeNotSet = errors.New("oldest message age function not set")
+	SentinelErrorNotAcknowledged        = errors.New("message not acknowledged")
 )
 
 type DeleteStrategy string
 
 type SQSConf struct {
-	Queue               string
+	Queue string
+	// QueueName is resolved into the Queue URL when the consumer is created, Queue being left empty.
+	QueueName string
+	// VerifyQueue checks that the queue exists when the consumer is created.
+	VerifyQueue bool
+	// StartupProbeAttempts is the number of times the QueueName resolution and the VerifyQueue check are attempted,
+	// waiting StartupProbeBackoff before the first retry and doubling the wait before each of the next ones.
+	StartupProbeAttempts int
+	StartupProbeBackoff  time.Duration
+	// BestEffortStartupProbes logs a failed VerifyQueue check instead of failing the consumer creation.
+	BestEffortStartupProbes bool
+	// ContainerCredentials makes NewSQSConsumer use the ECS container credentials endpoint as soon as
+	// AWS_CONTAINER_CREDENTIALS_RELATIVE_URI or AWS_CONTAINER_CREDENTIALS_FULL_URI is set, skipping the
+	// rest of the default credential chain and its IMDS timeout.
+	ContainerCredentials bool
+	// CredentialsProvider supplies the credentials of NewSQSConsumer instead of the AWS environment keys and the
+	// default credential chain, for credentials rotated without restarting the consumer. It is cached, and so
+	// called again once the credentials it returned expire.
+	CredentialsProvider aws.CredentialsProvider
+	// EndpointURL overrides the SQS endpoint of the client created by NewSQSConsumer and NewSQSConsumerWithAWSConfig,
+	// for local emulators or VPC endpoints for instance. EndpointResolver resolves the endpoint of every request
+	// instead, for FIPS, dualstack or custom per region endpoints, EndpointURL being its base endpoint.
+	EndpointURL      string
+	EndpointResolver sqs.EndpointResolverV2
+	// APIOptions adds middlewares to the stack of every request of the client created by NewSQSConsumer and
+	// NewSQSConsumerWithAWSConfig, after the ones of the aws.Config, for mandatory headers or request logging.
+	APIOptions []func(*middleware.Stack) error
+
 	Concurrency         int
 	MaxNumberOfMessages int32
 	VisibilityTimeout   int32
 	WaitTimeSeconds     int32
 	DeleteStrategy      DeleteStrategy
+	// AdaptiveBatchSize lowers the number of messages received at once, MaxNumberOfMessages being the ceiling,
+	// so that a worker consumes them within half the visibility timeout given the average consumption duration.
+	// The queue is assumed to use the SQS default visibility timeout of 30 seconds when VisibilityTimeout is unset.
+	AdaptiveBatchSize bool
+	// AdaptiveWaitTime doubles the long polling wait of a worker after every receive without messages, up to the
+	// 20 seconds SQS allows, and returns it to WaitTimeSeconds as soon as messages are received. Idle queues are
+	// polled with fewer requests while busy ones keep the WaitTimeSeconds latency.
+	AdaptiveWaitTime bool
+	// InitialVisibilityExtension sets the visibility timeout of the messages right after they are received,
+	// for consumptions known to outlast the queue visibility timeout. Zero keeps the received visibility timeout.
+	InitialVisibilityExtension time.Duration
+	// VisibilityHeartbeat extends, every VisibilityHeartbeat, the visibility timeout of the messages being consumed
+	// by VisibilityTimeout, or the SQS default of 30 seconds when unset, so that consumptions outlasting it are not
+	// redelivered. It must be shorter than that timeout and doesn't apply to DeleteStrategyImmediate.
+	VisibilityHeartbeat time.Duration
+	// VisibilityDeadline sets the deadline of the consumer function context to the visibility expiry of its message,
+	// less VisibilityDeadlineMargin, so that the consumption is cancelled before the message is redelivered and
+	// consumed twice. The expiry follows InitialVisibilityExtension and, with VisibilityHeartbeat, is the 12 hours
+	// SQS hides a message at most.
+	VisibilityDeadline       bool
+	VisibilityDeadlineMargin time.Duration
+	// MaxHandlerRetryDuration bounds the in-process retries of a message, see RetryDeadline, to this long after it
+	// was received. Unless the message was deleted on receipt, the bound is at most DefaultRetryVisibilityFactor
+	// of its visibility timeout, so that retries stop before the message is redelivered.
+	MaxHandlerRetryDuration time.Duration
+	// RetryBudgetRate enables a retry budget shared by the workers, a token bucket refilled with RetryBudgetRate
+	// tokens per second up to RetryBudgetBurst, which defaults to one second of refill. Every in-process retry
+	// takes a token, see AllowRetry, so that a failing dependency doesn't multiply the load it receives: once the
+	// budget is exhausted the failed messages are left to SQS redelivery. Zero disables the budget.
+	RetryBudgetRate  float64
+	RetryBudgetBurst int
+	// SystemAttributeNames restricts the system attributes received with the messages, all of them by default.
+	// The attributes required by the enabled features are added with a warning when missing.
+	SystemAttributeNames []types.MessageSystemAttributeName
+
+	// ReceiveErrorClassifier defaults to DefaultReceiveErrorClassifier.
+	ReceiveErrorClassifier ReceiveErrorClassifier
+	ThrottleBackoff        time.Duration
+	MaxThrottleBackoff     time.Duration
+	TransientErrorDelay    time.Duration
+	// MinPollInterval is the minimum time between the start of two receives of a worker, capping the receive
+	// requests at Concurrency per MinPollInterval. Receives are only delayed when the previous one started less
+	// than MinPollInterval ago. Zero doesn't pace the receives.
+	MinPollInterval time.Duration
+	// EmptyReceiveJitter adds a random delay up to EmptyReceiveJitter to the one second wait after a receive without
+	// consumable messages, so that the workers polling a near empty queue don't wake up together.
+	// Defaults to DefaultEmptyReceiveJitter.
+	EmptyReceiveJitter time.Duration
+	// UnderDeliveryWindow enables a check of the in-flight limit of the queue, 120,000 messages or 20,000 for FIFO
+	// queues, past which receives return few or no messages. When the receives of every worker over a window got
+	// less than a tenth of the requested messages while the queue reports visible ones, a warning is logged,
+	// UnderDeliveredTotal is incremented and OnUnderDelivery is called with the requested and received counts.
+	// Long visibility timeouts of slowly deleted messages are the usual cause. Zero disables the check.
+	UnderDeliveryWindow time.Duration
+	OnUnderDelivery     func(requested, received int)
+	// OldestMessageAgeInterval enables reading the age of the oldest message of the queue with OldestMessageAge
+	// every OldestMessageAgeInterval while the consumer runs, the lag of the consumer to scale and alert on. The age
+	// is returned by SQS.OldestMessageAge and recorded by the Metrics implementing OldestMessageAgeRecorder.
+	// Zero disables the reads, OldestMessageAge being required otherwise.
+	OldestMessageAgeInterval time.Duration
+	OldestMessageAge         OldestMessageAgeFunc
+	// QueueDepthInterval enables reading the approximate number of visible, in flight and delayed messages of the
+	// queue every QueueDepthInterval while the consumer runs, for the Metrics implementing QueueDepthRecorder.
+	QueueDepthInterval time.Duration
+	// QueueDoesNotExistThreshold is the number of consecutive QueueDoesNotExist receive errors retried after
+	// TransientErrorDelay, whatever the classifier, before Start returns SentinelErrorQueueDoesNotExist.
+	// Zero returns on the first one.
+	QueueDoesNotExistThreshold int
+
+	// TTLAttribute names a message attribute holding either a duration relative to the SentTimestamp ("90s"),
+	// an RFC 3339 timestamp or a Unix timestamp in seconds. Expired messages are deleted without being consumed.
+	TTLAttribute string
+	// ClockSkewTolerance is the difference between the local clock and the SQS or producer ones tolerated by the
+	// computations comparing message timestamps to the local time: messages only expire ClockSkewTolerance after
+	// their TTL, and a warning is logged when received messages show a larger skew. Ages are never negative.
+	// Defaults to DefaultClockSkewTolerance.
+	ClockSkewTolerance time.Duration
+
+	// MinSentTimestamp skips the consumer function for the messages sent before it, for instance to discard the
+	// messages of a faulty producer window. They are handled according to StaleMessageAction, which defaults to
+	// DecodeErrorDelete.
+	MinSentTimestamp   time.Time
+	StaleMessageAction DecodeErrorAction
+
+	// SplitJSONArray calls the consumer function once per element of the message bodies holding a JSON array,
+	// with the attributes of the message, other bodies being consumed as is. It is all or nothing: the message is
+	// acknowledged once every element was consumed, while the first failing element stops the consumption and
+	// leaves the whole message to redelivery, its elements consumed so far included, so the consumer function must
+	// be idempotent. It doesn't apply to StartBatch and StartWithResult.
+	SplitJSONArray bool
+
+	// Filter drops the messages it returns false for: they are deleted without being consumed.
+	Filter func(msg Message) bool
+
+	// ProcessDecider declines the messages it returns false for, to roll a new consumer function out to a share of
+	// the messages. Declined messages are consumed by DeclinedConsumer when set, otherwise they are made visible
+	// again right away for another consumer, running the previous version for instance, and DeclinedTotal is
+	// incremented. Every receive of a message counts towards the maxReceiveCount of the queue redrive policy, so
+	// a message declined by every consumer long enough ends up in the dead letter queue, and one declined by a
+	// consumer deciding at random can be received again by the same one. With DeleteStrategyImmediate the
+	// messages are deleted on receipt: the declined ones are lost unless DeclinedConsumer is set.
+	// DeclinedConsumer doesn't apply to StartBatch and StartWithResult, the declined messages being left.
+	ProcessDecider   func(msg Message) bool
+	DeclinedConsumer ContextConsumerFn
+
+	// BatchSorter orders the messages of every receive before they are consumed, receive order by default.
+	// Messages it leaves out of the returned slice are neither consumed nor deleted.
+	BatchSorter func(msgs []Message) []Message
+
+	// DedupWindow drops the messages whose idempotency key was consumed successfully within the window:
+	// they are deleted without being consumed. The keys are kept in memory unless IdempotencyStore is set.
+	DedupWindow time.Duration
+	// IdempotencyStore shares the idempotency keys between consumer instances, DedupWindow being the ttl of the keys.
+	// Deduplication is disabled when both are unset.
+	IdempotencyStore IdempotencyStore
+	// IdempotencyKeyFunc returns the idempotency key of a message, defaults to IdempotencyByMessageID.
+	// Messages it returns false for are always consumed.
+	IdempotencyKeyFunc func(msg Message) (string, bool)
+
+	// CircuitBreakerThreshold is the number of consecutive consumer function failures that stops polling
+	// for CircuitBreakerCooldown. Zero disables the circuit breaker.
+	CircuitBreakerThreshold int
+	CircuitBreakerCooldown  time.Duration
+
+	// FIFO consumes a FIFO queue: it enables PartitionByGroup and, unless SystemAttributeNames is set, requests only
+	// the MessageGroupId, SequenceNumber and MessageDeduplicationId system attributes along with the ones required
+	// by the other enabled features. Received messages missing one of them are logged.
+	FIFO bool
+	// ReceiveRequestAttemptIDFunc returns the ReceiveRequestAttemptId of the receives of FIFO queues, defaults to
+	// NewReceiveRequestAttemptID. A receive retried after an error reuses the id of the failed one, so that SQS
+	// returns the messages it may have received instead of leaving them invisible until their visibility timeout.
+	// The id is logged with receive errors and returned by ReceiveRequestAttemptID.
+	ReceiveRequestAttemptIDFunc func() string
+
+	// PartitionByGroup spreads messages over Concurrency partitions by hashing their group key, so that messages
+	// of a group are consumed one at a time and in receive order while different groups are consumed concurrently.
+	PartitionByGroup bool
+	// GroupKeyExtractor returns the group of a message, defaults to GroupByMessageGroupID.
+	// Messages without a group are spread by MessageId.
+	GroupKeyExtractor func(msg Message) string
+
+	// TotalShards, when above one, makes the consumer consume only the messages ShardFilter assigns to ShardIndex,
+	// from 0 to TotalShards-1, given their group key, see GroupKeyExtractor. The other messages are made visible
+	// again right after they are received, for the replicas consuming the other shards. SQS doesn't route messages
+	// to replicas: every message is received on average TotalShards times before being consumed, which raises
+	// the receive requests and the ApproximateReceiveCount the queue redrive policy relies on, and a shard without
+	// a running replica is never consumed. It trades throughput for locality, caches keyed by group for instance.
+	TotalShards int
+	ShardIndex  int
+	// ShardFilter reports whether the messages with the key belong to myShard, defaults to ShardByHash.
+	ShardFilter func(key string, totalShards, myShard int) bool
+
+	// ContinueOnDeleteError logs failed deletes and keeps the worker running, the undeleted messages being
+	// redelivered once their visibility timeout expires. By default a failed delete stops the consumer.
+	ContinueOnDeleteError bool
+
+	// DeleteRetryBufferSize buffers up to DeleteRetryBufferSize messages whose delete was throttled instead of
+	// failing the worker. Every worker retries them before its next receive, backing off from ThrottleBackoff to
+	// MaxThrottleBackoff while the deletes are throttled, and Start deletes the remaining ones before returning.
+	// Messages exceeding the buffer are dropped with a warning and redelivered once their visibility timeout
+	// expires. Zero handles throttled deletes like the other delete errors.
+	DeleteRetryBufferSize int
+
+	// OnDelete is called with the MessageId of the messages SQS confirmed deleted, after every delete batch.
+	OnDelete func(msgIDs []string)
+	// OnDeleteError is called with the entries SQS failed to delete, after every delete batch having some.
+	// The messages are redelivered once their visibility timeout expires.
+	OnDeleteError func(failed []types.BatchResultErrorEntry)
+
+	// StreamBatches releases the messages of a receive one at a time: a message is only referenced until its consume
+	// function returns, after which its MessageId and ReceiptHandle are kept for the delete. Without it, the whole
+	// received batch, bodies included, is held until every message of the batch is consumed. Along with MaxInFlight,
+	// it bounds the memory of queues with large payloads. It doesn't apply to StartBatch.
+	StreamBatches bool
+
+	// MaxInFlight caps the number of messages consumed at the same time across all workers. Zero means no limit.
+	MaxInFlight int
+	// MaxInFlightPerGroup caps the number of messages of a group, given by GroupKeyExtractor, consumed at the same
+	// time across all workers, so that a hot key doesn't take every worker. The messages of a saturated group are
+	// made visible again right after they are received and GroupSaturatedTotal is incremented: every receive
+	// counts towards the maxReceiveCount of the queue redrive policy. It doesn't apply to DeleteStrategyImmediate,
+	// whose messages are deleted on receipt. Zero means no limit. See GroupInFlight.
+	MaxInFlightPerGroup int
+	// GroupRateLimit paces the messages of every group, given by GroupKeyExtractor, to GroupRateLimit per second
+	// with bursts of GroupRateBurst, which defaults to one second of messages, for downstreams limiting every
+	// tenant. A message waits for its group before being consumed, holding its worker, or its partition with
+	// PartitionByGroup, and GroupRateLimitedTotal is incremented; the visibility timeout must allow for the wait.
+	// The limiters are created on the first message of a group and evicted once idle. Zero disables it.
+	GroupRateLimit float64
+	GroupRateBurst int
+	// WindowSize and WindowDuration bound the windows of StartWindowed: a window is flushed once it holds
+	// WindowSize messages or WindowDuration after its first message was received, DefaultWindowSize and
+	// DefaultWindowDuration when zero.
+	WindowSize     int
+	WindowDuration time.Duration
+	// ConsumeConcurrently consumes the messages of a receive on their own goroutines instead of one after the other.
+	// A worker starts at most MaxInFlight of them, so there are at most Concurrency × min(MaxInFlight,
+	// MaxNumberOfMessages) consumer goroutines, MaxInFlight of them consuming at once. It doesn't apply with
+	// PartitionByGroup, whose groups are consumed sequentially, nor to StartBatch.
+	ConsumeConcurrently bool
+
+	// ShutdownSignals stop the consumer gracefully, like Stop, defaults to DefaultShutdownSignals. They are only
+	// relayed to the consumer while Start runs, the default behavior of the signals being restored once it returns.
+	ShutdownSignals []os.Signal
+	// DisableSignalHandling leaves the signals to the application, which stops the consumer with Stop or its context.
+	DisableSignalHandling bool
+	// DrainTimeout is how long Stop and the ShutdownSignals let the workers consume the messages they received
+	// before cancelling their context, defaults to DefaultDrainTimeout in NewSQSConsumer. It should stay below
+	// DefaultCloseTimeout and the stop timeout of the platform, 30 seconds on ECS and Kubernetes by default.
+	DrainTimeout time.Duration
+
+	// OnShutdownUnprocessed is called once the workers stopped, before Start returns, with the messages received
+	// and left undeleted because the consumer was stopping: the ones whose consumption failed or was cancelled,
+	// and the ones whose delete failed, buffered acknowledgements included. They will be redelivered. It is not
+	// called when there are none, nor for the messages deleted on receipt by DeleteStrategyImmediate. With
+	// StreamBatches, the messages only hold their MessageId and ReceiptHandle.
+	OnShutdownUnprocessed func(msgs []Message)
+
+	// AfterPoll is called by every worker at the end of each poll cycle with the number of messages handed to the
+	// consumer function and the error stopping the worker, if any. It must be safe for concurrent use.
+	AfterPoll func(processed int, err error)
+
+	// OnQueueEmpty is called when every worker received no message after the queue had work,
+	// OnQueueNonEmpty when a worker receives messages after the queue was empty.
+	OnQueueEmpty    func()
+	OnQueueNonEmpty func()
+
+	// ExtendedClient consumes the payloads the SQS extended client library stored in S3 with S3Client
+	// instead of the S3 pointer carried by the message.
+	ExtendedClient bool
+	S3Client       S3Client
+
+	// DecompressBodies decompresses the bodies of the messages whose ContentEncodingAttribute is gzip before they
+	// are consumed. SQS bodies being text, the compressed bodies are expected base64 encoded. The messages failing
+	// to decompress are handled according to DecompressErrorAction, which defaults to DecodeErrorAction.
+	// ContentEncodingAttribute defaults to DefaultContentEncodingAttribute.
+	DecompressBodies         bool
+	ContentEncodingAttribute string
+	DecompressErrorAction    DecodeErrorAction
+
+	// BodyCharset transcodes the message bodies to UTF-8 before they are consumed, for producers using a legacy
+	// encoding. Bodies are passed as is when nil. The messages failing to transcode are handled according to
+	// BodyCharsetErrorAction, which defaults to DecodeErrorAction.
+	BodyCharset            BodyDecoder
+	BodyCharsetErrorAction DecodeErrorAction
+
+	// UnwrapEventBridge consumes the detail of the messages delivered by an EventBridge rule instead of their event
+	// envelope. The detail-type and source of the event are passed as the EventBridgeDetailTypeAttribute and
+	// EventBridgeSourceAttribute message attributes. Other messages, SNS notifications included, are left as is.
+	UnwrapEventBridge bool
+
+	// SlowHandlerThreshold logs a warning for every consumption taking longer. Zero disables it.
+	SlowHandlerThreshold time.Duration
+
+	// PanicThreshold is the number of consume function panics within PanicWindow, for the messages received by
+	// a worker, that triggers PanicPolicy. Panics are recovered and handled as consume function errors.
+	PanicThreshold int
+	PanicWindow    time.Duration
+	PanicPolicy    PanicPolicy
+	// PanicsAreFatal stops the consumer on the first consume function panic, which Start raises again once the
+	// workers stopped, the consumed messages were deleted and OnShutdownUnprocessed was called, instead of
+	// handling it as an error. PanicThreshold doesn't apply then. Panics recovered by a middleware.Recover of the
+	// consumer function are errors the consumer never sees, so it must be left out of the chain for fail fast runs.
+	PanicsAreFatal bool
+
+	// DeadLetterQueueURL receives the messages a ResultConsumerFn sends to the dead letter queue.
+	DeadLetterQueueURL string
+	// DiscoverDeadLetterQueue sets DeadLetterQueueURL, when empty, to the dead letter queue of the queue RedrivePolicy
+	// once the consumer is created, so that the application and SQS share the same dead letter queue. It is a
+	// startup probe: a queue without redrive policy fails the consumer creation unless BestEffortStartupProbes is set.
+	DiscoverDeadLetterQueue bool
+	// DecodeErrorAction handles the messages whose consumer function returned a DecodeError, see Typed. It defaults
+	// to DecodeErrorDeadLetter when DeadLetterQueueURL is set and to DecodeErrorDelete otherwise, so that malformed
+	// messages are not redelivered forever.
+	DecodeErrorAction DecodeErrorAction
+	// ErrorClassifier decides what happens to the messages whose consumer function returned an error other than a
+	// DecodeError: redelivered, sent to DeadLetterQueueURL or deleted as poison, or deleted as if consumed. It
+	// defaults to DefaultErrorClassifier, redelivering them. It doesn't apply to StartBatch and StartWindowed.
+	ErrorClassifier ErrorClassifier
+	// ErrorVisibilityTimeout returns how long a message failing with an ErrorRetryable error stays invisible before
+	// it is redelivered, instead of the visibility timeout, for retry delays depending on the error: short for a
+	// lock conflict, long for a rate limit. It is rounded up to the second and bounded to 12 hours, SQS maximum, and
+	// zero keeps the visibility timeout. It doesn't apply to DeleteStrategyImmediate.
+	ErrorVisibilityTimeout func(err error) time.Duration
+
+	// MaxBodyBytes skips the consumer function for the messages whose body, extended client payload included,
+	// is larger. They are handled according to OversizedBodyAction, which defaults like DecodeErrorAction.
+	// Zero means no limit.
+	MaxBodyBytes        int
+	OversizedBodyAction DecodeErrorAction
+
+	// HandlerDeps is handed to the consumer functions through their context, see DepsFromContext. It is shared by
+	// every consumption and must be safe for concurrent use.
+	HandlerDeps any
+
+	// ShouldTrace selects the messages whose consumption is logged in detail, at the info level whatever the
+	// logger level, and marked for the consumer function, see Traced. It doesn't apply to batch consumption.
+	ShouldTrace func(msg Message) bool
+
+	// Metrics records the consumer measurements, nothing is recorded when nil.
+	Metrics MetricsRecorder
+
+	// AuditWriter receives a JSON line per consumed message, see AuditRecord, written while Start runs.
+	// The records are buffered and dropped with a warning when the writer can't keep up.
+	AuditWriter io.Writer
+
+	// Name identifies the consumer in its logs, as the "consumer" attribute, and in its audit records. It defaults
+	// to the queue name, the last path segment of the queue URL.
+	Name string
+	// Logger defaults to slog.Default().
+	Logger *slog.Logger
+	// LogStatsOnShutdown logs the Stats totals once Start returns.
+	LogStatsOnShutdown bool
+	// LogSampleEvery logs one in every LogSampleEvery per message informational records and LogSamplePerSecond
+	// caps them per second, zero disabling either limit. Warnings and errors are always logged.
+	LogSampleEvery     int
+	LogSamplePerSecond int
 }
 
 type SQSClient interface {
 	ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error)
 	DeleteMessageBatch(ctx context.Context, params *sqs.DeleteMessageBatchInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageBatchOutput, error)
+	SendMessage(ctx context.Context, params *sqs.SendMessageInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error)
+	ChangeMessageVisibility(ctx context.Context, params *sqs.ChangeMessageVisibilityInput, optFns ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error)
+	ChangeMessageVisibilityBatch(ctx context.Context, params *sqs.ChangeMessageVisibilityBatchInput, optFns ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityBatchOutput, error)
+	GetQueueUrl(ctx context.Context, params *sqs.GetQueueUrlInput, optFns ...func(*sqs.Options)) (*sqs.GetQueueUrlOutput, error)
+	GetQueueAttributes(ctx context.Context, params *sqs.GetQueueAttributesInput, optFns ...func(*sqs.Options)) (*sqs.GetQueueAttributesOutput, error)
 }
 
 type SQS struct {
-	config *SQSConf
-	sqs    SQSClient
+	config  *SQSConf
+	sqs     SQSClient
+	pending deleteBuffer
+	// deleteRetries holds the throttled deletes with DeleteRetryBufferSize
+	deleteRetries deleteRetries
+	underDelivery underDelivery
+	retryBudget   retryBudget
+	groupInFlight groupInFlight
+	groupRate     groupRate
+	window        window
+	unprocessed   unprocessed
+	fatalPanic    atomic.Pointer[recovered]
+	stats         stats
+	breaker       breaker
+	partitions    partitions
+	// workQueue are the partitions of the running consumer, for WorkQueueDepth
+	workQueue atomic.Pointer[partitions]
+	inFlight  *semaphore.Weighted
+
+	transitions    transitions
+	ready          readiness
+	idempotency    idempotency
+	adaptive       adaptive
+	auditLog       *auditLog
+	clock          clock
+	namedLogger    lazyLogger
+	logSampling    lazyLogger
+	deleteStrategy atomic.Pointer[DeleteStrategy]
+
+	// oldestMessageAge is the last age read with OldestMessageAgeInterval, in nanoseconds
+	oldestMessageAge atomic.Int64
+	// skewWarned is when the last clock skew warning was logged, in Unix nanoseconds
+	skewWarned atomic.Int64
+
+	stopMu sync.Mutex
+	stop   context.CancelFunc
+	// stopPolling ends the receives of the workers, see Stop
+	stopPolling context.CancelFunc
+	stopped     chan struct{}
+}
+
+// worker is the state a polling goroutine keeps across its poll cycles.
+type worker struct {
+	started   bool
+	throttled int
+	missing   int
+	empty     bool
+	panics    panicWindow
+	// strategy is the DeleteStrategy of the current poll cycle
+	strategy DeleteStrategy
+	// received is when the messages being consumed were received
+	received time.Time
+	// polled is when the worker last called ReceiveMessage with MinPollInterval
+	polled time.Time
+	// waitTime is the WaitTimeSeconds of the next receive with AdaptiveWaitTime, zero for WaitTimeSeconds
+	waitTime int32
+	// attemptID is the ReceiveRequestAttemptId of the last receive on a FIFO queue, started at attemptStarted,
+	// attemptFailed when it returned an error
+	attemptID      string
+	attemptStarted time.Time
+	attemptFailed  bool
+	inProgress     inProgress
+	// polling is done once Stop was called, the worker receiving no more messages; nil outside of Start
+	polling context.Context
 }
 
 type ConsumerFn func(data []byte, attributes map[string]types.MessageAttributeValue) error
+
+type ContextConsumerFn func(ctx context.Context, data []byte, attributes map[string]types.MessageAttributeValue) error
+
+// Middleware decorates a consumer function, see the middleware package for ready-made ones.
+type Middleware func(next ContextConsumerFn) ContextConsumerFn
+
+// BatchConsumerFn consumes a received batch and returns the MessageId of the messages to delete.
+type BatchConsumerFn func(msgs []Message) ([]string, error)
+
+// batchHandler consumes the messages of a received batch and returns the ones to acknowledge.
+// Partitioned handlers consume each message on its group partition when PartitionByGroup is enabled.
+type batchHandler struct {
+	consume     func(ctx context.Context, w *worker, msgs []types.Message) []types.Message
+	partitioned bool
+	// declined consumes the messages declined by ProcessDecider, which are left when it is nil
+	declined func(ctx context.Context, w *worker, msgs []types.Message) []types.Message
+	// background runs alongside the workers until ctx is done, unless nil
+	background func(ctx context.Context)
+}
+
+type deleteBuffer struct {
+	mu       sync.Mutex
+	messages []types.Message
+}
@@ -0,0 +1,100 @@
+package consumer
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+const (
+	// PanicPolicyStop stops the consumer, Start returning SentinelErrorPanicStorm.
+	PanicPolicyStop = PanicPolicy("STOP")
+	// PanicPolicyCircuit opens the circuit breaker, pausing polling for CircuitBreakerCooldown.
+	PanicPolicyCircuit = PanicPolicy("CIRCUIT")
+	// PanicPolicyContinue only logs the panic storm.
+	PanicPolicyContinue = PanicPolicy("CONTINUE")
+)
+
+// PanicPolicy is how the consumer reacts when the consume function panics PanicThreshold times
+// within PanicWindow for the messages received by the same worker.
+type PanicPolicy string
+
+// recovered holds the value of a consume function panic.
+type recovered struct {
+	value any
+}
+
+type panicWindow struct {
+	mu     sync.Mutex
+	at     []time.Time
+	stormy bool
+}
+
+// protect calls fn, turning a panic into an error wrapping SentinelErrorHandlerPanic.
+func (s *SQS) protect(w *worker, fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", SentinelErrorHandlerPanic, r)
+			s.logger().Error("panic in consume function", slog.Any("panic", r), slog.String("stack", string(debug.Stack())))
+			if s.config.PanicsAreFatal {
+				s.fatalPanic.CompareAndSwap(nil, &recovered{value: r})
+				s.cancel()
+				return
+			}
+			s.panicked(w)
+		}
+	}()
+
+	return fn()
+}
+
+func (s *SQS) panicked(w *worker) {
+	if s.config.PanicThreshold <= 0 {
+		return
+	}
+
+	p := &w.panics
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := s.now()
+	p.at = append(p.at, now)
+	for len(p.at) > 0 && now.Sub(p.at[0]) > s.config.PanicWindow {
+		p.at = p.at[1:]
+	}
+
+	if len(p.at) < s.config.PanicThreshold {
+		return
+	}
+	p.at = nil
+
+	s.logger().Error("panic storm in consume function",
+		slog.Int("panics", s.config.PanicThreshold),
+		slog.Duration("window", s.config.PanicWindow),
+		slog.String("policy", string(s.config.PanicPolicy)))
+
+	switch s.config.PanicPolicy {
+	case PanicPolicyCircuit:
+		s.breaker.open(now)
+	case PanicPolicyContinue:
+	default:
+		p.stormy = true
+	}
+}
+
+// repanic raises again the first consume function panic recovered with PanicsAreFatal.
+func (s *SQS) repanic() {
+	if r := s.fatalPanic.Load(); r != nil {
+		panic(r.value)
+	}
+}
+
+// panicStorm reports whether the worker must stop under PanicPolicyStop.
+func (w *worker) panicStorm() bool {
+	w.panics.mu.Lock()
+	defer w.panics.mu.Unlock()
+
+	return w.panics.stormy
+}
@@ -0,0 +1,83 @@
+package consumer
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestSQS_panicStorm(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      PanicPolicy
+		wantStorm   bool
+		wantCircuit CircuitState
+	}{
+		{name: "shouldStopWorker", policy: PanicPolicyStop, wantStorm: true, wantCircuit: CircuitClosed},
+		{name: "shouldOpenCircuit", policy: PanicPolicyCircuit, wantStorm: false, wantCircuit: CircuitOpen},
+		{name: "shouldContinue", policy: PanicPolicyContinue, wantStorm: false, wantCircuit: CircuitClosed},
+	}
+
+	consumeFn := func(_ context.Context, data []byte, attributes map[string]types.MessageAttributeValue) error {
+		if string(data) != "msg1" {
+			panic("boom")
+		}
+		return nil
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &SQS{config: &SQSConf{
+				Queue:          "queue",
+				DeleteStrategy: DeleteStrategyOnSuccess,
+				PanicThreshold: 2,
+				PanicWindow:    time.Minute,
+				PanicPolicy:    tt.policy,
+				Logger:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+			}}
+			w := &worker{}
+
+			toDelete, _ := s.consumeMessages(context.Background(), w, getQueueContent().Messages, s.consumeEach(consumeFn))
+			assert.Len(t, toDelete, 1)
+			assert.Equal(t, int64(2), s.Stats().FailedTotal)
+			assert.Equal(t, tt.wantStorm, w.panicStorm())
+			assert.Equal(t, tt.wantCircuit, s.breaker.current())
+		})
+	}
+}
+
+func TestSQS_PanicsAreFatal(t *testing.T) {
+	sqsMock := new(SqsMock)
+	sqsMock.On("ReceiveMessage", mock.Anything, mock.AnythingOfType("*sqs.ReceiveMessageInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+	sqsMock.On("DeleteMessageBatch", mock.Anything, mock.AnythingOfType("*sqs.DeleteMessageBatchInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+
+	s := &SQS{sqs: sqsMock, config: &SQSConf{
+		Queue:                 "queue",
+		Concurrency:           1,
+		DeleteStrategy:        DeleteStrategyOnSuccess,
+		DisableSignalHandling: true,
+		PanicsAreFatal:        true,
+		Logger:                slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}}
+
+	assert.PanicsWithValue(t, "boom", func() {
+		_ = s.StartWithContext(context.Background(), func(ctx context.Context, data []byte, _ map[string]types.MessageAttributeValue) error {
+			if string(data) == "msg2" {
+				panic("boom")
+			}
+			return ctx.Err()
+		})
+	})
+
+	require.Len(t, sqsMock.deleteInputs, 1)
+	assert.Equal(t, "msg1", aws.ToString(sqsMock.deleteInputs[0].Entries[0].Id))
+}
@@ -0,0 +1,119 @@
+package consumer
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"hash/fnv"
+	"sync/atomic"
+)
+
+// partitions run the messages sharing a group key sequentially on the same goroutine.
+type partitions []partition
+
+type partition chan func()
+
+func newPartitions(n int) partitions {
+	p := make(partitions, n)
+	for i := range p {
+		p[i] = make(partition, maxBatchSize)
+	}
+	return p
+}
+
+func (p partition) run(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case task := <-p:
+			task()
+		}
+	}
+}
+
+// consume dispatches messages to their partition and waits for them to be consumed, returning the non nil results
+// of consumeFn. The entries of messages are cleared once dispatched, the tasks holding the only references.
+// Once ctx is done, the tasks not started yet are skipped while the running ones are waited for, so that the
+// messages consumed while stopping are acknowledged. Unless nil, blocked is called when a partition is full, the
+// function it returns once the message was dispatched.
+func (p partitions) consume(ctx context.Context, messages []types.Message, groupKey func(types.Message) string, consumeFn func(types.Message) *types.Message, blocked func() func()) []types.Message {
+	results := make(chan *types.Message, len(messages))
+	started := make([]atomic.Bool, len(messages))
+	dispatched := make([]int, 0, len(messages))
+
+	for i, msg := range messages {
+		messages[i] = types.Message{}
+		task := func() {
+			if started[i].CompareAndSwap(false, true) {
+				results <- consumeFn(msg)
+			}
+		}
+
+		target := p[p.index(groupKey(msg))]
+		select {
+		case target <- task:
+			dispatched = append(dispatched, i)
+			continue
+		default:
+		}
+
+		var unblocked func()
+		if blocked != nil {
+			unblocked = blocked()
+		}
+		select {
+		case target <- task:
+			dispatched = append(dispatched, i)
+		case <-ctx.Done():
+		}
+		if unblocked != nil {
+			unblocked()
+		}
+	}
+
+	consumed := make([]types.Message, 0, len(dispatched))
+	pending := len(dispatched)
+	done := ctx.Done()
+	for pending > 0 {
+		select {
+		case msg := <-results:
+			pending--
+			if msg != nil {
+				consumed = append(consumed, *msg)
+			}
+		case <-done:
+			done = nil
+			for _, i := range dispatched {
+				if started[i].CompareAndSwap(false, true) {
+					pending--
+				}
+			}
+		}
+	}
+
+	return consumed
+}
+
+func (p partitions) index(key string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32() % uint32(len(p)))
+}
+
+// GroupByMessageGroupID returns the MessageGroupId of FIFO queue messages.
+func GroupByMessageGroupID(msg Message) string {
+	return msg.MessageGroupID
+}
+
+func (s *SQS) groupKey(msg types.Message) string {
+	extract := s.config.GroupKeyExtractor
+	if extract == nil {
+		extract = GroupByMessageGroupID
+	}
+
+	if key := extract(s.message(msg)); key != "" {
+		return key
+	}
+	return aws.ToString(msg.MessageId)
+}
@@ -0,0 +1,126 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/semaphore"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPartitions_consume(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p := newPartitions(4)
+	g, ctx := errgroup.WithContext(ctx)
+	for _, part := range p {
+		g.Go(func() error {
+			return part.run(ctx)
+		})
+	}
+
+	messages := make([]types.Message, 0)
+	for i := 0; i < 8; i++ {
+		messages = append(messages, types.Message{
+			MessageId:  aws.String(fmt.Sprintf("msg%d", i)),
+			Attributes: map[string]string{"MessageGroupId": fmt.Sprintf("group%d", i%2)},
+		})
+	}
+
+	s := &SQS{config: &SQSConf{}}
+
+	var mu sync.Mutex
+	running := map[string]int{}
+	order := map[string][]string{}
+	overlap := false
+
+	consumed := p.consume(ctx, messages, s.groupKey, func(msg types.Message) *types.Message {
+		group := msg.Attributes["MessageGroupId"]
+
+		mu.Lock()
+		running[group]++
+		overlap = overlap || running[group] > 1
+		order[group] = append(order[group], *msg.MessageId)
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		running[group]--
+		mu.Unlock()
+
+		if *msg.MessageId == "msg7" {
+			return nil
+		}
+		return &msg
+	}, nil)
+
+	assert.Len(t, consumed, 7)
+	assert.False(t, overlap, "messages of a group must not be consumed concurrently")
+	assert.Equal(t, []string{"msg0", "msg2", "msg4", "msg6"}, order["group0"])
+	assert.Equal(t, []string{"msg1", "msg3", "msg5", "msg7"}, order["group1"])
+}
+
+func TestPartitions_consumeStopping(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p := newPartitions(1)
+	go func() { _ = p[0].run(ctx) }()
+
+	messages := make([]types.Message, 0)
+	for i := 0; i < 3; i++ {
+		messages = append(messages, types.Message{MessageId: aws.String(fmt.Sprintf("msg%d", i))})
+	}
+
+	s := &SQS{config: &SQSConf{}}
+	consumed := p.consume(ctx, messages, s.groupKey, func(msg types.Message) *types.Message {
+		cancel()
+		time.Sleep(20 * time.Millisecond)
+		return &msg
+	}, nil)
+
+	assert.Len(t, consumed, 1, "the running task must be waited for, the queued ones skipped")
+	assert.Equal(t, "msg0", aws.ToString(consumed[0].MessageId))
+}
+
+func TestSQS_MaxInFlight(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s := &SQS{
+		config:     &SQSConf{DeleteStrategy: DeleteStrategyOnSuccess, MaxInFlight: 1},
+		partitions: newPartitions(4),
+		inFlight:   semaphore.NewWeighted(1),
+	}
+	for _, part := range s.partitions {
+		go func() { _ = part.run(ctx) }()
+	}
+
+	var mu sync.Mutex
+	running, peak := 0, 0
+	consumeFn := func(_ context.Context, data []byte, attributes map[string]types.MessageAttributeValue) error {
+		mu.Lock()
+		running++
+		peak = max(peak, running)
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return nil
+	}
+
+	toDelete, consumed := s.consumeMessages(ctx, &worker{}, getQueueContent().Messages, s.consumeEach(consumeFn))
+	assert.Len(t, toDelete, 3)
+	assert.Equal(t, 3, consumed)
+	assert.Equal(t, 1, peak)
+}
@@ -0,0 +1,54 @@
+package consumer
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// Peek receives messages and hands them to fn without consuming them: they are never deleted. The received
+// messages stay hidden from the other consumers while Peek runs, for up to VisibilityTimeout, so that every receive
+// returns new ones, and their visibility timeout is reset to zero when Peek returns so that they return to the queue
+// at once. Every receive counts toward the ApproximateReceiveCount of the messages and thus toward the queue redrive
+// policy.
+//
+// Peek returns when fn returns false, when a receive yields no message it has not handed to fn yet, or once ctx is
+// done. fn is handed every message once, the received messages being kept in memory until Peek returns.
+func (s *SQS) Peek(ctx context.Context, fn func(msg Message) bool) error {
+	received := make(map[string]types.Message)
+	defer func() {
+		msgs := make([]types.Message, 0, len(received))
+		for _, msg := range received {
+			msgs = append(msgs, msg)
+		}
+		s.extendVisibility(context.WithoutCancel(ctx), msgs, 0)
+	}()
+
+	for ctx.Err() == nil {
+		result, err := s.sqs.ReceiveMessage(ctx, s.pullMessagesRequest())
+		if err != nil {
+			return err
+		}
+
+		unseen := make([]types.Message, 0, len(result.Messages))
+		for _, msg := range result.Messages {
+			id := aws.ToString(msg.MessageId)
+			if _, ok := received[id]; !ok {
+				unseen = append(unseen, msg)
+			}
+			// the latest receipt handle is the one that can reset the visibility
+			received[id] = msg
+		}
+		if len(unseen) == 0 {
+			return nil
+		}
+
+		for _, msg := range unseen {
+			if !fn(s.message(msg)) {
+				return nil
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// TransformFn returns the body and attributes a piped message is sent with.
+type TransformFn func(data []byte, attributes map[string]types.MessageAttributeValue) ([]byte, map[string]types.MessageAttributeValue, error)
+
+// Pipe consumes the queue, sending every message transformed to destURL. A message is deleted once sent, so that
+// it is sent at least once: it is redelivered when transform or the send fails. Pipe requires a DeleteStrategy
+// deleting messages on success. Messages sent to a FIFO queue keep their MessageGroupId, their source MessageId
+// deduplicating the redeliveries.
+func (s *SQS) Pipe(ctx context.Context, transform TransformFn, destURL string) error {
+	if strategy := s.DeleteStrategy(); strategy != DeleteStrategyOnSuccess && strategy != DeleteStrategyBatched {
+		return fmt.Errorf("%w: Pipe requires DeleteStrategyOnSuccess or DeleteStrategyBatched", SentinelErrorInvalidConfig)
+	}
+
+	return s.StartWithContext(ctx, func(ctx context.Context, data []byte, attributes map[string]types.MessageAttributeValue) error {
+		body, attributes, err := transform(data, attributes)
+		if err != nil {
+			return err
+		}
+
+		in := PublishInput{QueueURL: destURL, Body: body, Attributes: attributes}
+		if isFIFO(destURL) {
+			msg, _ := MessageFromContext(ctx)
+			in.MessageGroupID = msg.MessageGroupID
+			if in.MessageGroupID == "" {
+				in.MessageGroupID = aws.ToString(msg.MessageId)
+			}
+			in.DeduplicationID = aws.ToString(msg.MessageId)
+		}
+
+		_, err = s.Publish(ctx, in)
+		return err
+	})
+}
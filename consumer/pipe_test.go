@@ -0,0 +1,48 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestSQS_Pipe(t *testing.T) {
+	sqsMock := new(SqsMock)
+	sqsMock.On("ReceiveMessage", mock.Anything, mock.AnythingOfType("*sqs.ReceiveMessageInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+	sqsMock.On("SendMessage", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+	sqsMock.On("DeleteMessageBatch", mock.Anything, mock.AnythingOfType("*sqs.DeleteMessageBatchInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, errors.New("fake delete error"))
+
+	s := &SQS{sqs: sqsMock, config: &SQSConf{Queue: "queue", Concurrency: 1, DeleteStrategy: DeleteStrategyOnSuccess}}
+
+	transform := func(data []byte, attributes map[string]types.MessageAttributeValue) ([]byte, map[string]types.MessageAttributeValue, error) {
+		if string(data) == "msg2" {
+			return nil, nil, errors.New("fake transform error")
+		}
+		return []byte(strings.ToUpper(string(data))), attributes, nil
+	}
+
+	err := s.Pipe(context.Background(), transform, "dest.fifo")
+	require.Error(t, err)
+
+	require.Len(t, sqsMock.sendInputs, 2)
+	assert.Equal(t, "MSG1", aws.ToString(sqsMock.sendInputs[0].MessageBody))
+	assert.Equal(t, "dest.fifo", aws.ToString(sqsMock.sendInputs[0].QueueUrl))
+	assert.Equal(t, "msg1", aws.ToString(sqsMock.sendInputs[0].MessageDeduplicationId))
+	assert.Equal(t, "MSG3", aws.ToString(sqsMock.sendInputs[1].MessageBody))
+
+	require.Len(t, sqsMock.deleteInputs, 1)
+	require.Len(t, sqsMock.deleteInputs[0].Entries, 2)
+	assert.Equal(t, "msg1", aws.ToString(sqsMock.deleteInputs[0].Entries[0].Id))
+	assert.Equal(t, "msg3", aws.ToString(sqsMock.deleteInputs[0].Entries[1].Id))
+
+	err = (&SQS{config: &SQSConf{Queue: "queue", DeleteStrategy: DeleteStrategyImmediate}}).Pipe(context.Background(), transform, "dest")
+	assert.ErrorIs(t, err, SentinelErrorInvalidConfig)
+}
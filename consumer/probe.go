@@ -0,0 +1,91 @@
+package consumer
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"log/slog"
+)
+
+// startupProbes reports whether the configuration requires calls to SQS when the consumer is created.
+func (c *SQSConf) startupProbes() bool {
+	return c.QueueName != "" || c.VerifyQueue || c.discoverDeadLetterQueue()
+}
+
+// discoverDeadLetterQueue reports whether DeadLetterQueueURL is read from the redrive policy of the queue.
+func (c *SQSConf) discoverDeadLetterQueue() bool {
+	return c.DiscoverDeadLetterQueue && c.DeadLetterQueueURL == ""
+}
+
+// runStartupProbes resolves QueueName into Queue, checks that the queue exists when VerifyQueue is enabled and
+// discovers the dead letter queue with DiscoverDeadLetterQueue. A queue URL that can't be resolved always fails,
+// the consumer being unable to run without it.
+func (s *SQS) runStartupProbes(ctx context.Context) error {
+	if s.config.Queue == "" {
+		err := s.probe(ctx, "GetQueueUrl", func() error {
+			out, err := s.sqs.GetQueueUrl(ctx, &sqs.GetQueueUrlInput{QueueName: aws.String(s.config.QueueName)})
+			if err != nil {
+				return err
+			}
+			s.config.Queue = aws.ToString(out.QueueUrl)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if s.config.VerifyQueue {
+		err := s.probe(ctx, "GetQueueAttributes", func() error {
+			_, err := s.sqs.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+				QueueUrl:       aws.String(s.config.Queue),
+				AttributeNames: []types.QueueAttributeName{types.QueueAttributeNameQueueArn},
+			})
+			return err
+		})
+		if err != nil && !s.config.BestEffortStartupProbes {
+			return err
+		}
+		if err != nil {
+			s.logger().Warn("queue could not be verified, starting anyway", slog.Any("error", err.Error()), requestIDAttr(err))
+		}
+	}
+
+	if s.config.discoverDeadLetterQueue() {
+		err := s.probe(ctx, "RedrivePolicy", func() error {
+			return s.discoverDeadLetterQueue(ctx)
+		})
+		if err != nil && !s.config.BestEffortStartupProbes {
+			return err
+		}
+		if err != nil {
+			s.logger().Warn("dead letter queue could not be discovered, starting without it", slog.Any("error", err.Error()),
+				requestIDAttr(err))
+		}
+	}
+
+	return nil
+}
+
+// probe calls fn up to StartupProbeAttempts times, waiting StartupProbeBackoff before the first retry and doubling
+// the wait before each of the next ones.
+func (s *SQS) probe(ctx context.Context, name string, fn func() error) error {
+	err := fn()
+
+	for attempt, wait := 1, s.config.StartupProbeBackoff; err != nil && attempt < s.config.StartupProbeAttempts; attempt, wait = attempt+1, wait*2 {
+		s.logger().Warn("startup probe failed, retrying",
+			slog.String("probe", name),
+			slog.Int("attempt", attempt),
+			slog.Any("error", err.Error()),
+			requestIDAttr(err))
+
+		s.sleep(ctx, wait)
+		if ctx.Err() != nil {
+			return err
+		}
+		err = fn()
+	}
+
+	return err
+}
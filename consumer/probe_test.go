@@ -0,0 +1,99 @@
+package consumer
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestNewSQSStartupProbes(t *testing.T) {
+	probeErr := errors.New("fake probe error")
+
+	tests := []struct {
+		name       string
+		conf       SQSConf
+		urlErrs    int
+		verifyErrs int
+		attributes map[string]string
+		wantErr    error
+		wantQueue  string
+		wantDLQ    string
+	}{
+		{
+			name:      "shouldResolveQueueName",
+			conf:      SQSConf{QueueName: "orders"},
+			wantQueue: "https://sqs.eu-west-1.amazonaws.com/123456789012/orders",
+		},
+		{
+			name:      "shouldRetryQueueName",
+			conf:      SQSConf{QueueName: "orders"},
+			urlErrs:   2,
+			wantQueue: "https://sqs.eu-west-1.amazonaws.com/123456789012/orders",
+		},
+		{
+			name:    "shouldFailQueueNameAfterAttempts",
+			conf:    SQSConf{QueueName: "orders", BestEffortStartupProbes: true},
+			urlErrs: 3,
+			wantErr: probeErr,
+		},
+		{
+			name:       "shouldFailVerifyQueueStrict",
+			conf:       SQSConf{Queue: "queue", VerifyQueue: true},
+			verifyErrs: 3,
+			wantErr:    probeErr,
+		},
+		{
+			name:       "shouldProceedVerifyQueueBestEffort",
+			conf:       SQSConf{Queue: "queue", VerifyQueue: true, BestEffortStartupProbes: true},
+			verifyErrs: 3,
+			wantQueue:  "queue",
+		},
+		{
+			name:       "shouldDiscoverDeadLetterQueue",
+			conf:       SQSConf{Queue: "queue", DiscoverDeadLetterQueue: true, DecodeErrorAction: DecodeErrorDeadLetter},
+			attributes: map[string]string{"RedrivePolicy": `{"deadLetterTargetArn":"arn:aws:sqs:eu-west-1:123456789012:orders-dlq","maxReceiveCount":5}`},
+			wantQueue:  "queue",
+			wantDLQ:    "https://sqs.eu-west-1.amazonaws.com/123456789012/orders-dlq",
+		},
+		{
+			name:    "shouldFailWithoutRedrivePolicy",
+			conf:    SQSConf{Queue: "queue", DiscoverDeadLetterQueue: true},
+			wantErr: SentinelErrorDeadLetterQueueNotSet,
+		},
+		{
+			name:      "shouldKeepDeadLetterQueueURL",
+			conf:      SQSConf{Queue: "queue", DiscoverDeadLetterQueue: true, DeadLetterQueueURL: "dlq"},
+			wantQueue: "queue",
+			wantDLQ:   "dlq",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqsMock := &SqsMock{queueAttributes: tt.attributes}
+			if tt.urlErrs > 0 {
+				sqsMock.On("GetQueueUrl", mock.Anything, mock.Anything, mock.Anything).Return(nil, probeErr).Times(tt.urlErrs)
+			}
+			sqsMock.On("GetQueueUrl", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+			if tt.verifyErrs > 0 {
+				sqsMock.On("GetQueueAttributes", mock.Anything, mock.Anything, mock.Anything).Return(nil, probeErr).Times(tt.verifyErrs)
+			}
+			sqsMock.On("GetQueueAttributes", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+
+			conf := tt.conf
+			conf.StartupProbeBackoff = time.Millisecond
+
+			s, err := newSQS(sqsMock, &conf)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantQueue, s.config.Queue)
+			assert.Equal(t, tt.wantDLQ, s.config.DeadLetterQueueURL)
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package consumer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"strconv"
+	"strings"
+)
+
+// Attrs is a set of message attributes, built with StringAttr, NumberAttr and BinaryAttr:
+//
+//	Attributes: consumer.Attrs{"type": consumer.StringAttr("order"), "version": consumer.NumberAttr(2)}
+type Attrs = map[string]types.MessageAttributeValue
+
+// Number are the types of the values of NumberAttr.
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~float32 | ~float64
+}
+
+// StringAttr returns a String message attribute.
+func StringAttr(v string) types.MessageAttributeValue {
+	return types.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(v)}
+}
+
+// NumberAttr returns a Number message attribute.
+func NumberAttr[N Number](n N) types.MessageAttributeValue {
+	var v string
+	switch n := any(n).(type) {
+	case float32:
+		v = strconv.FormatFloat(float64(n), 'f', -1, 32)
+	case float64:
+		v = strconv.FormatFloat(n, 'f', -1, 64)
+	default:
+		v = fmt.Sprint(n)
+	}
+	return types.MessageAttributeValue{DataType: aws.String("Number"), StringValue: aws.String(v)}
+}
+
+// BinaryAttr returns a Binary message attribute.
+func BinaryAttr(b []byte) types.MessageAttributeValue {
+	return types.MessageAttributeValue{DataType: aws.String("Binary"), BinaryValue: b}
+}
+
+// PublishInput is a message sent by Publish.
+type PublishInput struct {
+	// QueueURL defaults to the consumed queue.
+	QueueURL     string
+	Body         []byte
+	Attributes   map[string]types.MessageAttributeValue
+	DelaySeconds int32
+
+	// MessageGroupID is required when publishing to a FIFO queue.
+	MessageGroupID string
+	// DeduplicationID is derived from the SHA-256 of the body when publishing to a FIFO queue,
+	// unless ContentBasedDeduplication is enabled on that queue.
+	DeduplicationID           string
+	ContentBasedDeduplication bool
+}
+
+// Publish sends a message and returns its MessageId.
+func (s *SQS) Publish(ctx context.Context, in PublishInput) (string, error) {
+	queueURL := in.QueueURL
+	if queueURL == "" {
+		queueURL = s.config.Queue
+	}
+
+	req := &sqs.SendMessageInput{
+		QueueUrl:          aws.String(queueURL),
+		MessageBody:       aws.String(string(in.Body)),
+		MessageAttributes: in.Attributes,
+		DelaySeconds:      in.DelaySeconds,
+	}
+
+	if isFIFO(queueURL) {
+		if in.MessageGroupID == "" {
+			return "", SentinelErrorGroupIDNotSet
+		}
+		req.MessageGroupId = aws.String(in.MessageGroupID)
+		// per message delays are not supported by FIFO queues
+		req.DelaySeconds = 0
+
+		switch {
+		case in.DeduplicationID != "":
+			req.MessageDeduplicationId = aws.String(in.DeduplicationID)
+		case !in.ContentBasedDeduplication:
+			req.MessageDeduplicationId = aws.String(deduplicationID(in.Body))
+		}
+	}
+
+	out, err := s.sqs.SendMessage(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	return aws.ToString(out.MessageId), nil
+}
+
+func isFIFO(queueURL string) bool {
+	return strings.HasSuffix(queueURL, ".fifo")
+}
+
+func deduplicationID(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,85 @@
+package consumer
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSQS_Publish(t *testing.T) {
+	tests := []struct {
+		name      string
+		in        PublishInput
+		wantGroup *string
+		wantDedup *string
+		wantErr   error
+	}{
+		{
+			name: "shouldPublishStandard",
+			in:   PublishInput{QueueURL: "queue", Body: []byte("msg1"), MessageGroupID: "group"},
+		},
+		{
+			name:      "shouldDeriveDeduplicationID",
+			in:        PublishInput{QueueURL: "queue.fifo", Body: []byte("msg1"), MessageGroupID: "group"},
+			wantGroup: aws.String("group"),
+			wantDedup: aws.String(deduplicationID([]byte("msg1"))),
+		},
+		{
+			name:      "shouldKeepDeduplicationID",
+			in:        PublishInput{QueueURL: "queue.fifo", Body: []byte("msg1"), MessageGroupID: "group", DeduplicationID: "dedup"},
+			wantGroup: aws.String("group"),
+			wantDedup: aws.String("dedup"),
+		},
+		{
+			name:      "shouldRelyOnContentBasedDeduplication",
+			in:        PublishInput{QueueURL: "queue.fifo", Body: []byte("msg1"), MessageGroupID: "group", ContentBasedDeduplication: true},
+			wantGroup: aws.String("group"),
+		},
+		{
+			name:    "shouldErrorMissingGroup",
+			in:      PublishInput{QueueURL: "queue.fifo", Body: []byte("msg1")},
+			wantErr: SentinelErrorGroupIDNotSet,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqsMock := new(SqsMock)
+			sqsMock.On("SendMessage", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+			s := &SQS{config: &SQSConf{Queue: "queue"}, sqs: sqsMock}
+
+			id, err := s.Publish(context.Background(), tt.in)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "sent", id)
+			require.Len(t, sqsMock.sendInputs, 1)
+			assert.Equal(t, tt.wantGroup, sqsMock.sendInputs[0].MessageGroupId)
+			assert.Equal(t, tt.wantDedup, sqsMock.sendInputs[0].MessageDeduplicationId)
+		})
+	}
+}
+
+func TestAttrs(t *testing.T) {
+	attrs := Attrs{
+		"type":    StringAttr("order"),
+		"version": NumberAttr(2),
+		"amount":  NumberAttr(12.50),
+		"small":   NumberAttr(float32(0.1)),
+		"payload": BinaryAttr([]byte{0x1f, 0x8b}),
+	}
+
+	assert.Equal(t, map[string]types.MessageAttributeValue{
+		"type":    {DataType: aws.String("String"), StringValue: aws.String("order")},
+		"version": {DataType: aws.String("Number"), StringValue: aws.String("2")},
+		"amount":  {DataType: aws.String("Number"), StringValue: aws.String("12.5")},
+		"small":   {DataType: aws.String("Number"), StringValue: aws.String("0.1")},
+		"payload": {DataType: aws.String("Binary"), BinaryValue: []byte{0x1f, 0x8b}},
+	}, attrs)
+}
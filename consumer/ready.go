@@ -0,0 +1,48 @@
+package consumer
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+type readiness struct {
+	once    sync.Once
+	ch      chan struct{}
+	workers atomic.Int32
+}
+
+// Started returns a channel closed once every worker is polling, right before its first receive request, which
+// long polls for up to WaitTimeSeconds.
+func (s *SQS) Started() <-chan struct{} {
+	return s.readyChan()
+}
+
+// WaitReady blocks until every worker is polling, see Started, or ctx is done.
+func (s *SQS) WaitReady(ctx context.Context) error {
+	select {
+	case <-s.readyChan():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *SQS) readyChan() chan struct{} {
+	s.ready.once.Do(func() {
+		s.ready.ch = make(chan struct{})
+	})
+	return s.ready.ch
+}
+
+// workerStarted is called by every worker before its first receive request.
+func (s *SQS) workerStarted(w *worker) {
+	if w.started {
+		return
+	}
+	w.started = true
+
+	if int(s.ready.workers.Add(1)) == s.config.Concurrency {
+		close(s.readyChan())
+	}
+}
@@ -0,0 +1,92 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// Redrive moves up to max messages from the dead letter queue dlqURL back to sourceURL, like the SQS console
+// redrive, and returns the number of messages moved. dlqURL defaults to DeadLetterQueueURL and sourceURL to the
+// consumed queue. Every message is sent with its body and attributes, then deleted from the dead letter queue.
+//
+// Redrive returns once max messages were moved, once the dead letter queue returns no message, or on the first
+// batch some messages of which failed: the messages that could not be sent stay in the dead letter queue and
+// become visible again after its visibility timeout, the ones sent but not deleted are redelivered to the source
+// queue and will be moved again by the next Redrive.
+func (s *SQS) Redrive(ctx context.Context, dlqURL, sourceURL string, max int) (int, error) {
+	if dlqURL == "" {
+		dlqURL = s.config.DeadLetterQueueURL
+	}
+	if dlqURL == "" {
+		return 0, SentinelErrorDeadLetterQueueNotSet
+	}
+	if sourceURL == "" {
+		sourceURL = s.config.Queue
+	}
+
+	moved := 0
+	for moved < max {
+		out, err := s.sqs.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+			MessageSystemAttributeNames: []types.MessageSystemAttributeName{types.MessageSystemAttributeNameAll},
+			MessageAttributeNames:       []string{"All"},
+			QueueUrl:                    aws.String(dlqURL),
+			MaxNumberOfMessages:         int32(min(maxBatchSize, max-moved)),
+		})
+		if err != nil {
+			return moved, err
+		}
+		if len(out.Messages) == 0 {
+			return moved, nil
+		}
+
+		n, err := s.redriveBatch(ctx, dlqURL, sourceURL, out.Messages[:min(len(out.Messages), max-moved)])
+		moved += n
+		if err != nil {
+			return moved, err
+		}
+	}
+
+	return moved, nil
+}
+
+// redriveBatch sends msgs to sourceURL, deletes the ones sent from dlqURL and returns how many were deleted.
+func (s *SQS) redriveBatch(ctx context.Context, dlqURL, sourceURL string, msgs []types.Message) (int, error) {
+	var errs []error
+	sent := make([]types.DeleteMessageBatchRequestEntry, 0, len(msgs))
+	for _, msg := range msgs {
+		_, err := s.Publish(ctx, PublishInput{
+			QueueURL:        sourceURL,
+			Body:            []byte(aws.ToString(msg.Body)),
+			Attributes:      msg.MessageAttributes,
+			MessageGroupID:  s.message(msg).MessageGroupID,
+			DeduplicationID: aws.ToString(msg.MessageId),
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("sending message %s: %w", aws.ToString(msg.MessageId), err))
+			continue
+		}
+		sent = append(sent, types.DeleteMessageBatchRequestEntry{Id: msg.MessageId, ReceiptHandle: msg.ReceiptHandle})
+	}
+
+	if len(sent) == 0 {
+		return 0, errors.Join(errs...)
+	}
+
+	out, err := s.sqs.DeleteMessageBatch(ctx, &sqs.DeleteMessageBatchInput{
+		Entries:  sent,
+		QueueUrl: aws.String(dlqURL),
+	})
+	if err != nil {
+		return 0, errors.Join(append(errs, fmt.Errorf("deleting redriven messages: %w", err))...)
+	}
+
+	for _, failed := range out.Failed {
+		errs = append(errs, fmt.Errorf("deleting redriven message %s: %s: %s", aws.ToString(failed.Id),
+			aws.ToString(failed.Code), aws.ToString(failed.Message)))
+	}
+	return len(out.Successful), errors.Join(errs...)
+}
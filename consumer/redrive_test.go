@@ -0,0 +1,67 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func TestSQS_Redrive(t *testing.T) {
+	tests := []struct {
+		name         string
+		max          int
+		sendErr      error
+		failDeleteID string
+		wantMoved    int
+		wantSent     int
+		wantErr      bool
+	}{
+		{name: "shouldMoveUpToMax", max: 5, wantMoved: 5, wantSent: 5},
+		{name: "shouldStopOnSendFailure", max: 5, sendErr: errors.New("send failed"), wantMoved: 2, wantSent: 3, wantErr: true},
+		{name: "shouldStopOnDeleteFailure", max: 5, failDeleteID: "msg2", wantMoved: 2, wantSent: 3, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqsMock := new(SqsMock)
+			sqsMock.On("ReceiveMessage", mock.Anything, mock.AnythingOfType("*sqs.ReceiveMessageInput"),
+				mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+			if tt.sendErr != nil {
+				sqsMock.On("SendMessage", mock.Anything, mock.AnythingOfType("*sqs.SendMessageInput"),
+					mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, tt.sendErr).Once()
+			}
+			sqsMock.On("SendMessage", mock.Anything, mock.AnythingOfType("*sqs.SendMessageInput"),
+				mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+			sqsMock.On("DeleteMessageBatch", mock.Anything, mock.AnythingOfType("*sqs.DeleteMessageBatchInput"),
+				mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+			sqsMock.failDeleteID = tt.failDeleteID
+
+			s := &SQS{sqs: sqsMock, config: &SQSConf{Queue: "source", DeadLetterQueueURL: "dlq"}}
+
+			moved, err := s.Redrive(context.Background(), "", "", tt.max)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantMoved, moved)
+			assert.Len(t, sqsMock.sendInputs, tt.wantSent)
+
+			assert.Equal(t, "dlq", aws.ToString(sqsMock.inputs[0].QueueUrl))
+			assert.Equal(t, int32(5), sqsMock.inputs[0].MaxNumberOfMessages)
+			assert.Equal(t, "source", aws.ToString(sqsMock.sendInputs[0].QueueUrl))
+			assert.Equal(t, "dlq", aws.ToString(sqsMock.deleteInputs[0].QueueUrl))
+			assert.Contains(t, sqsMock.sendInputs[0].MessageAttributes, "attribute1")
+		})
+	}
+}
+
+func TestSQS_RedriveRequiresDeadLetterQueue(t *testing.T) {
+	s := &SQS{config: &SQSConf{Queue: "source"}}
+
+	_, err := s.Redrive(context.Background(), "", "", 10)
+	assert.ErrorIs(t, err, SentinelErrorDeadLetterQueueNotSet)
+}
@@ -0,0 +1,53 @@
+package consumer
+
+import (
+	"errors"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/smithy-go"
+	"log/slog"
+)
+
+// ConsumerError is an SQS request failure returned by Start, along with the AWS request ID to hand AWS support.
+type ConsumerError struct {
+	// Operation is the SQS API operation that failed, ReceiveMessage for instance.
+	Operation string
+	// RequestID is empty when the request got no response.
+	RequestID string
+	Err       error
+}
+
+// Error returns the SDK error message, which holds the request ID.
+func (e *ConsumerError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ConsumerError) Unwrap() error {
+	return e.Err
+}
+
+// RequestID returns the AWS request ID of an SQS request error, empty when the request got no response.
+func RequestID(err error) string {
+	var respErr *awshttp.ResponseError
+	if errors.As(err, &respErr) {
+		return respErr.ServiceRequestID()
+	}
+	return ""
+}
+
+// consumerError wraps the SDK operation errors into a ConsumerError, leaving the others as they are.
+func consumerError(err error) error {
+	var opErr *smithy.OperationError
+	if !errors.As(err, &opErr) {
+		return err
+	}
+	return &ConsumerError{Operation: opErr.Operation(), RequestID: RequestID(err), Err: err}
+}
+
+// requestIDAttr logs the AWS request ID of err, the empty attribute slog ignores when it has none.
+func requestIDAttr(err error) slog.Attr {
+	id := RequestID(err)
+	if id == "" {
+		return slog.Attr{}
+	}
+	return slog.String("requestId", id)
+}
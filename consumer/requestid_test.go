@@ -0,0 +1,57 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"log/slog"
+	"net/http"
+	"testing"
+)
+
+func operationError(operation, requestID string) error {
+	return &smithy.OperationError{
+		ServiceID:     "SQS",
+		OperationName: operation,
+		Err: &awshttp.ResponseError{
+			ResponseError: &smithyhttp.ResponseError{
+				Response: &smithyhttp.Response{Response: &http.Response{StatusCode: http.StatusForbidden}},
+				Err:      &smithy.GenericAPIError{Code: "AccessDenied", Message: "access denied"},
+			},
+			RequestID: requestID,
+		},
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	assert.Equal(t, "req-1", RequestID(operationError("ReceiveMessage", "req-1")))
+	assert.Empty(t, RequestID(errors.New("no response")))
+
+	assert.Equal(t, "req-1", requestIDAttr(operationError("ReceiveMessage", "req-1")).Value.String())
+	assert.True(t, requestIDAttr(errors.New("no response")).Equal(slog.Attr{}))
+}
+
+func TestSQS_StartReturnsConsumerError(t *testing.T) {
+	sqsMock := new(SqsMock)
+	sqsMock.On("ReceiveMessage", mock.Anything, mock.AnythingOfType("*sqs.ReceiveMessageInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, operationError("ReceiveMessage", "req-1"))
+
+	s := &SQS{sqs: sqsMock, config: &SQSConf{Queue: "queue", Concurrency: 1, DisableSignalHandling: true,
+		DeleteStrategy: DeleteStrategyOnSuccess}}
+
+	err := s.Start(context.Background(), func([]byte, map[string]types.MessageAttributeValue) error { return nil })
+
+	var consumerErr *ConsumerError
+	assert.True(t, errors.As(err, &consumerErr))
+	assert.Equal(t, "ReceiveMessage", consumerErr.Operation)
+	assert.Equal(t, "req-1", consumerErr.RequestID)
+
+	var apiErr smithy.APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "AccessDenied", apiErr.ErrorCode())
+}
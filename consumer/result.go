@@ -0,0 +1,97 @@
+package consumer
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"log/slog"
+	"time"
+)
+
+// maxVisibilityTimeout is the longest visibility timeout SQS accepts.
+const maxVisibilityTimeout = 12 * time.Hour
+
+// Result tells the consumer what to do with a message consumed without error. The zero Result leaves the
+// message on the queue until its visibility timeout expires.
+type Result struct {
+	// Delete deletes the message according to the DeleteStrategy.
+	Delete bool
+	// RequeueAfter makes the message visible again after the duration instead of the visibility timeout.
+	RequeueAfter time.Duration
+	// ToDLQ sends the message to DeadLetterQueueURL and deletes it.
+	ToDLQ bool
+}
+
+// ResultConsumerFn consumes a message and returns what to do with it.
+type ResultConsumerFn func(ctx context.Context, data []byte, attributes map[string]types.MessageAttributeValue) (Result, error)
+
+func (s *SQS) consumeResults(consumeFn ResultConsumerFn) batchHandler {
+	return s.eachMessage(func(ctx context.Context, w *worker, msg types.Message) bool {
+		var res Result
+		var err error
+		ok := s.consumeOne(ctx, w, msg, func(msgCtx context.Context) error {
+			res, err = consumeFn(msgCtx, []byte(*msg.Body), msg.MessageAttributes)
+			return err
+		})
+		// failed consumptions were handled by consumeOne, a DecodeError possibly deleting the message
+		if !ok || err != nil {
+			return ok
+		}
+
+		return s.applyResult(ctx, msg, res)
+	})
+}
+
+// applyResult acts on res and reports whether the message must be deleted.
+func (s *SQS) applyResult(ctx context.Context, msg types.Message, res Result) bool {
+	switch {
+	case res.ToDLQ:
+		if err := s.deadLetter(ctx, msg); err != nil {
+			s.logger().Error("error sending message to the dead letter queue",
+				slog.String("messageId", aws.ToString(msg.MessageId)),
+				slog.Any("error", err.Error()),
+				requestIDAttr(err))
+			return false
+		}
+		return true
+	case res.RequeueAfter > 0:
+		if err := s.changeVisibility(ctx, msg, res.RequeueAfter); err != nil {
+			s.logger().Error("error requeuing message",
+				slog.String("messageId", aws.ToString(msg.MessageId)),
+				slog.Any("error", err.Error()),
+				requestIDAttr(err))
+		}
+		return false
+	default:
+		return res.Delete
+	}
+}
+
+// deadLetter sends a copy of the message to DeadLetterQueueURL.
+func (s *SQS) deadLetter(ctx context.Context, msg types.Message) error {
+	if s.config.DeadLetterQueueURL == "" {
+		return SentinelErrorDeadLetterQueueNotSet
+	}
+
+	_, err := s.Publish(ctx, PublishInput{
+		QueueURL:        s.config.DeadLetterQueueURL,
+		Body:            []byte(aws.ToString(msg.Body)),
+		Attributes:      msg.MessageAttributes,
+		MessageGroupID:  s.message(msg).MessageGroupID,
+		DeduplicationID: aws.ToString(msg.MessageId),
+	})
+	return err
+}
+
+// changeVisibility makes the message visible again after d, rounded up to the second.
+func (s *SQS) changeVisibility(ctx context.Context, msg types.Message, d time.Duration) error {
+	d = min(d, maxVisibilityTimeout)
+
+	_, err := s.sqs.ChangeMessageVisibility(ctx, &sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          aws.String(s.config.Queue),
+		ReceiptHandle:     msg.ReceiptHandle,
+		VisibilityTimeout: int32((d + time.Second - 1) / time.Second),
+	})
+	return err
+}
@@ -0,0 +1,69 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func TestSQS_consumeResults(t *testing.T) {
+	sqsMock := new(SqsMock)
+	sqsMock.On("SendMessage", mock.Anything, mock.AnythingOfType("*sqs.SendMessageInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+	sqsMock.On("ChangeMessageVisibility", mock.Anything, mock.AnythingOfType("*sqs.ChangeMessageVisibilityInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+
+	s := &SQS{sqs: sqsMock, config: &SQSConf{
+		Queue:              "queue",
+		DeleteStrategy:     DeleteStrategyOnSuccess,
+		DeadLetterQueueURL: "dlq",
+	}}
+
+	consumeFn := func(_ context.Context, data []byte, attributes map[string]types.MessageAttributeValue) (Result, error) {
+		switch string(data) {
+		case "msg1":
+			return Result{Delete: true}, nil
+		case "msg2":
+			return Result{RequeueAfter: 1500 * time.Millisecond}, nil
+		default:
+			return Result{ToDLQ: true}, nil
+		}
+	}
+
+	toDelete, n := s.consumeMessages(context.Background(), &worker{}, getQueueContent().Messages, s.consumeResults(consumeFn))
+	assert.Equal(t, 3, n)
+	assert.Len(t, toDelete, 2)
+	assert.Equal(t, "msg1", *toDelete[0].MessageId)
+	assert.Equal(t, "msg3", *toDelete[1].MessageId)
+
+	assert.Len(t, sqsMock.visibilityInputs, 1)
+	assert.Equal(t, int32(2), sqsMock.visibilityInputs[0].VisibilityTimeout)
+
+	assert.Len(t, sqsMock.sendInputs, 1)
+	assert.Equal(t, "dlq", aws.ToString(sqsMock.sendInputs[0].QueueUrl))
+	assert.Equal(t, "msg3", aws.ToString(sqsMock.sendInputs[0].MessageBody))
+}
+
+func TestSQS_consumeResultsErrors(t *testing.T) {
+	s := &SQS{sqs: new(SqsMock), config: &SQSConf{Queue: "queue", DeleteStrategy: DeleteStrategyOnSuccess}}
+
+	consumeFn := func(_ context.Context, data []byte, attributes map[string]types.MessageAttributeValue) (Result, error) {
+		if string(data) == "msg1" {
+			return Result{Delete: true}, errors.New("fake consume error")
+		}
+		return Result{ToDLQ: true}, nil
+	}
+
+	toDelete, _ := s.consumeMessages(context.Background(), &worker{}, getQueueContent().Messages, s.consumeResults(consumeFn))
+	assert.Empty(t, toDelete)
+	assert.Equal(t, int64(1), s.Stats().FailedTotal)
+	assert.ErrorIs(t, s.deadLetter(context.Background(), types.Message{}), SentinelErrorDeadLetterQueueNotSet)
+
+	s.config.DeleteStrategy = DeleteStrategyImmediate
+	assert.ErrorIs(t, s.StartWithResult(context.Background(), consumeFn), SentinelErrorInvalidConfig)
+}
@@ -0,0 +1,37 @@
+package consumer
+
+import (
+	"context"
+	"time"
+)
+
+type retryDeadlineKey struct{}
+
+// RetryDeadline returns the time after which the consumer function must stop retrying the message it is called for
+// and return its error, leaving the message to SQS redelivery or to the queue redrive policy. See
+// SQSConf.MaxHandlerRetryDuration.
+func RetryDeadline(ctx context.Context) (time.Time, bool) {
+	deadline, ok := ctx.Value(retryDeadlineKey{}).(time.Time)
+	return deadline, ok
+}
+
+// retryDeadline returns the retry deadline of the messages received by the worker: MaxHandlerRetryDuration after
+// the receive, capped to DefaultRetryVisibilityFactor of their visibility unless they were deleted on receipt.
+func (s *SQS) retryDeadline(w *worker) (time.Time, bool) {
+	if w.received.IsZero() {
+		return time.Time{}, false
+	}
+
+	bound := s.config.MaxHandlerRetryDuration
+	if s.strategy(w) != DeleteStrategyImmediate {
+		safe := time.Duration(float64(s.visibility(w)) * DefaultRetryVisibilityFactor)
+		if bound == 0 || bound > safe {
+			bound = safe
+		}
+	}
+
+	if bound == 0 {
+		return time.Time{}, false
+	}
+	return w.received.Add(bound), true
+}
@@ -0,0 +1,70 @@
+package consumer
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestSQS_retryDeadline(t *testing.T) {
+	tests := []struct {
+		name         string
+		conf         SQSConf
+		wantDeadline time.Duration
+	}{
+		{name: "shouldCapToVisibilityByDefault", conf: SQSConf{DeleteStrategy: DeleteStrategyOnSuccess}, wantDeadline: 24 * time.Second},
+		{
+			name:         "shouldUseMaxHandlerRetryDuration",
+			conf:         SQSConf{DeleteStrategy: DeleteStrategyOnSuccess, MaxHandlerRetryDuration: 10 * time.Second},
+			wantDeadline: 10 * time.Second,
+		},
+		{
+			name:         "shouldCapMaxHandlerRetryDuration",
+			conf:         SQSConf{DeleteStrategy: DeleteStrategyOnSuccess, VisibilityTimeout: 10, MaxHandlerRetryDuration: time.Minute},
+			wantDeadline: 8 * time.Second,
+		},
+		{
+			name:         "shouldUseInitialExtension",
+			conf:         SQSConf{DeleteStrategy: DeleteStrategyOnSuccess, InitialVisibilityExtension: 100 * time.Second},
+			wantDeadline: 80 * time.Second,
+		},
+		{name: "shouldNotBoundDeletedOnReceipt", conf: SQSConf{DeleteStrategy: DeleteStrategyImmediate}},
+		{
+			name:         "shouldBoundDeletedOnReceiptWithMaxHandlerRetryDuration",
+			conf:         SQSConf{DeleteStrategy: DeleteStrategyImmediate, MaxHandlerRetryDuration: time.Hour},
+			wantDeadline: time.Hour,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received := time.Unix(1700000000, 0)
+			s := &SQS{config: &tt.conf}
+
+			deadline, ok := s.retryDeadline(&worker{received: received})
+			assert.Equal(t, tt.wantDeadline != 0, ok)
+			if ok {
+				assert.Equal(t, received.Add(tt.wantDeadline), deadline)
+			}
+		})
+	}
+}
+
+func TestRetryDeadline(t *testing.T) {
+	received := time.Unix(1700000000, 0)
+	s := &SQS{config: &SQSConf{Queue: "queue", DeleteStrategy: DeleteStrategyOnSuccess, VisibilityTimeout: 10}}
+
+	var got time.Time
+	consumeFn := func(ctx context.Context, _ []byte, _ map[string]types.MessageAttributeValue) error {
+		got, _ = RetryDeadline(ctx)
+		return nil
+	}
+
+	messages := []types.Message{{MessageId: aws.String("msg1"), Body: aws.String("body")}}
+	s.consumeMessages(context.Background(), &worker{received: received}, messages, s.consumeEach(consumeFn))
+
+	assert.Equal(t, received.Add(8*time.Second), got)
+}
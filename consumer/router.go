@@ -0,0 +1,71 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// TypeExtractor returns the type of a message a Router dispatches on, empty when the message has none.
+type TypeExtractor func(ctx context.Context, data []byte, attributes map[string]types.MessageAttributeValue) string
+
+// TypeFromAttributes returns a TypeExtractor reading the first of the named string message attributes set.
+// Without names it reads the "type" attribute, then the "eventType" one.
+func TypeFromAttributes(names ...string) TypeExtractor {
+	if len(names) == 0 {
+		names = []string{"type", "eventType"}
+	}
+
+	return func(_ context.Context, _ []byte, attributes map[string]types.MessageAttributeValue) string {
+		for _, name := range names {
+			if v := aws.ToString(attributes[name].StringValue); v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+}
+
+// Router dispatches messages to a consumer function per message type, use Consume as the consumer function.
+// Its zero value routes nothing until Handle is called.
+type Router struct {
+	routes map[string]ContextConsumerFn
+
+	// TypeExtractor defaults to TypeFromAttributes().
+	TypeExtractor TypeExtractor
+	// Fallback consumes the messages without a type or whose type has no route. When nil they fail with
+	// SentinelErrorNoRoute and are redelivered.
+	Fallback ContextConsumerFn
+}
+
+func NewRouter() *Router {
+	return &Router{routes: make(map[string]ContextConsumerFn)}
+}
+
+// Handle routes the messages of messageType to consumeFn.
+func (r *Router) Handle(messageType string, consumeFn ContextConsumerFn) *Router {
+	if r.routes == nil {
+		r.routes = make(map[string]ContextConsumerFn)
+	}
+	r.routes[messageType] = consumeFn
+	return r
+}
+
+func (r *Router) Consume(ctx context.Context, data []byte, attributes map[string]types.MessageAttributeValue) error {
+	extract := r.TypeExtractor
+	if extract == nil {
+		extract = TypeFromAttributes()
+	}
+
+	messageType := extract(ctx, data, attributes)
+	if consumeFn, ok := r.routes[messageType]; ok && messageType != "" {
+		return consumeFn(ctx, data, attributes)
+	}
+
+	if r.Fallback != nil {
+		return r.Fallback(ctx, data, attributes)
+	}
+
+	return fmt.Errorf("%w: %q", SentinelErrorNoRoute, messageType)
+}
@@ -0,0 +1,56 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRouter_Consume(t *testing.T) {
+	var got []string
+	route := func(name string) ContextConsumerFn {
+		return func(_ context.Context, _ []byte, _ map[string]types.MessageAttributeValue) error {
+			got = append(got, name)
+			return nil
+		}
+	}
+	attrs := func(name, value string) map[string]types.MessageAttributeValue {
+		return map[string]types.MessageAttributeValue{name: {DataType: aws.String("String"), StringValue: aws.String(value)}}
+	}
+
+	r := NewRouter().Handle("created", route("created")).Handle("deleted", route("deleted"))
+
+	assert.NoError(t, r.Consume(context.Background(), nil, attrs("type", "created")))
+	assert.NoError(t, r.Consume(context.Background(), nil, attrs("eventType", "deleted")))
+	assert.ErrorIs(t, r.Consume(context.Background(), nil, nil), SentinelErrorNoRoute)
+	assert.ErrorIs(t, r.Consume(context.Background(), nil, attrs("type", "updated")), SentinelErrorNoRoute)
+
+	r.Fallback = route("fallback")
+	assert.NoError(t, r.Consume(context.Background(), nil, nil))
+	assert.NoError(t, r.Consume(context.Background(), nil, attrs("type", "updated")))
+
+	r.TypeExtractor = TypeFromAttributes("kind")
+	assert.NoError(t, r.Consume(context.Background(), nil, attrs("kind", "created")))
+	assert.NoError(t, r.Consume(context.Background(), nil, attrs("type", "created")))
+
+	r.TypeExtractor = func(_ context.Context, data []byte, _ map[string]types.MessageAttributeValue) string {
+		var body struct{ Kind string }
+		_ = json.Unmarshal(data, &body)
+		return body.Kind
+	}
+	assert.NoError(t, r.Consume(context.Background(), []byte(`{"Kind":"deleted"}`), attrs("type", "created")))
+
+	assert.Equal(t, []string{"created", "deleted", "fallback", "fallback", "created", "fallback", "deleted"}, got)
+}
+
+func TestRouter_ZeroValue(t *testing.T) {
+	var r Router
+	assert.ErrorIs(t, r.Consume(context.Background(), nil, nil), SentinelErrorNoRoute)
+
+	r.Handle("created", func(context.Context, []byte, map[string]types.MessageAttributeValue) error { return nil })
+	attrs := map[string]types.MessageAttributeValue{"type": {DataType: aws.String("String"), StringValue: aws.String("created")}}
+	assert.NoError(t, r.Consume(context.Background(), nil, attrs))
+}
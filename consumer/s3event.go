@@ -0,0 +1,106 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"net/url"
+	"time"
+)
+
+// s3TestEvent is the event S3 sends when notifications are configured on a bucket.
+const s3TestEvent = "s3:TestEvent"
+
+// S3Event is an S3 event notification.
+type S3Event struct {
+	Records []S3Record `json:"Records"`
+}
+
+// S3Record is one record of an S3 event notification. Object keys are URL decoded.
+type S3Record struct {
+	EventVersion string    `json:"eventVersion"`
+	EventSource  string    `json:"eventSource"`
+	AWSRegion    string    `json:"awsRegion"`
+	EventTime    time.Time `json:"eventTime"`
+	EventName    string    `json:"eventName"`
+	S3           S3Entity  `json:"s3"`
+}
+
+// S3Entity is the bucket and object an S3Record is about.
+type S3Entity struct {
+	ConfigurationID string   `json:"configurationId"`
+	Bucket          S3Bucket `json:"bucket"`
+	Object          S3Object `json:"object"`
+}
+
+// S3Bucket is the bucket of an S3Entity.
+type S3Bucket struct {
+	Name string `json:"name"`
+	ARN  string `json:"arn"`
+}
+
+// S3Object is the object of an S3Entity.
+type S3Object struct {
+	Key       string `json:"key"`
+	Size      int64  `json:"size"`
+	ETag      string `json:"eTag"`
+	VersionID string `json:"versionId"`
+	Sequencer string `json:"sequencer"`
+}
+
+// s3Envelope holds the fields of both the S3 test event and the SNS notification an S3 event can be wrapped in.
+type s3Envelope struct {
+	Event   string `json:"Event"`
+	Type    string `json:"Type"`
+	Message string `json:"Message"`
+}
+
+// ParseS3Event parses a message body holding an S3 event notification, sent directly or through SNS. The
+// s3:TestEvent sent when notifications are configured parses to an event without records.
+func ParseS3Event(body []byte) (S3Event, error) {
+	var envelope s3Envelope
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return S3Event{}, err
+	}
+
+	if envelope.Type == "Notification" {
+		return ParseS3Event([]byte(envelope.Message))
+	}
+
+	var event S3Event
+	if envelope.Event == s3TestEvent {
+		return event, nil
+	}
+
+	if err := json.Unmarshal(body, &event); err != nil {
+		return S3Event{}, err
+	}
+
+	for i := range event.Records {
+		if key, err := url.QueryUnescape(event.Records[i].S3.Object.Key); err == nil {
+			event.Records[i].S3.Object.Key = key
+		}
+	}
+
+	return event, nil
+}
+
+// StartS3Events is StartWithContext for queues receiving S3 event notifications, consumeFn being called with the
+// records of every event. Test events are deleted without calling consumeFn, bodies that fail to parse are
+// handled according to DecodeErrorAction.
+func (s *SQS) StartS3Events(ctx context.Context, consumeFn func(ctx context.Context, records []S3Record) error) error {
+	return s.StartWithContext(ctx, s3Events(consumeFn))
+}
+
+func s3Events(consumeFn func(ctx context.Context, records []S3Record) error) ContextConsumerFn {
+	return func(ctx context.Context, data []byte, _ map[string]types.MessageAttributeValue) error {
+		event, err := ParseS3Event(data)
+		if err != nil {
+			return &DecodeError{Err: err}
+		}
+		if len(event.Records) == 0 {
+			return nil
+		}
+		return consumeFn(ctx, event.Records)
+	}
+}
@@ -0,0 +1,75 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const s3EventBody = `{"Records":[{"eventVersion":"2.1","eventSource":"aws:s3","awsRegion":"eu-west-1",
+"eventTime":"2024-01-02T03:04:05.000Z","eventName":"ObjectCreated:Put",
+"s3":{"bucket":{"name":"bucket","arn":"arn:aws:s3:::bucket"},"object":{"key":"path/my+file%281%29.txt","size":42}}}]}`
+
+func TestParseS3Event(t *testing.T) {
+	wrapped, _ := json.Marshal(map[string]string{"Type": "Notification", "Message": s3EventBody})
+
+	tests := []struct {
+		name     string
+		body     string
+		wantKeys []string
+		wantErr  bool
+	}{
+		{name: "shouldParseEvent", body: s3EventBody, wantKeys: []string{"path/my file(1).txt"}},
+		{name: "shouldParseSNSWrappedEvent", body: string(wrapped), wantKeys: []string{"path/my file(1).txt"}},
+		{name: "shouldParseTestEventWithoutRecords", body: `{"Service":"Amazon S3","Event":"s3:TestEvent","Bucket":"bucket"}`},
+		{name: "shouldFailOnInvalidBody", body: `not json`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := ParseS3Event([]byte(tt.body))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			var keys []string
+			for _, record := range event.Records {
+				keys = append(keys, record.S3.Object.Key)
+				assert.Equal(t, "bucket", record.S3.Bucket.Name)
+				assert.Equal(t, "ObjectCreated:Put", record.EventName)
+			}
+			assert.Equal(t, tt.wantKeys, keys)
+		})
+	}
+}
+
+func TestS3Events(t *testing.T) {
+	s := &SQS{config: &SQSConf{Queue: "queue", DeleteStrategy: DeleteStrategyOnSuccess}}
+
+	messages := []types.Message{
+		{MessageId: aws.String("event"), Body: aws.String(s3EventBody)},
+		{MessageId: aws.String("test"), Body: aws.String(`{"Service":"Amazon S3","Event":"s3:TestEvent"}`)},
+		{MessageId: aws.String("invalid"), Body: aws.String(`not json`)},
+	}
+
+	calls := 0
+	consumeFn := s3Events(func(_ context.Context, records []S3Record) error {
+		calls++
+		assert.Len(t, records, 1)
+		return nil
+	})
+
+	toDelete, _ := s.consumeMessages(context.Background(), &worker{}, messages, s.consumeEach(consumeFn))
+
+	ids := make([]string, len(toDelete))
+	for i, msg := range toDelete {
+		ids[i] = *msg.MessageId
+	}
+	assert.Equal(t, []string{"event", "test", "invalid"}, ids)
+	assert.Equal(t, 1, calls)
+}
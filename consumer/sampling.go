@@ -0,0 +1,87 @@
+package consumer
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// sampler lets through one in every `every` records and at most perSecond records per second, zero disabling
+// either limit.
+type sampler struct {
+	every     int
+	perSecond int
+	now       func() time.Time
+
+	mu     sync.Mutex
+	seen   int
+	window time.Time
+	logged int
+}
+
+func (s *sampler) allow() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seen++
+	if s.every > 1 && (s.seen-1)%s.every != 0 {
+		return false
+	}
+
+	if s.perSecond > 0 {
+		if now := s.now(); now.Sub(s.window) >= time.Second {
+			s.window = now
+			s.logged = 0
+		}
+		if s.logged >= s.perSecond {
+			return false
+		}
+		s.logged++
+	}
+
+	return true
+}
+
+// sampledHandler samples the records below the warning level, warnings and errors being always handled.
+type sampledHandler struct {
+	slog.Handler
+	sampler *sampler
+}
+
+func (h *sampledHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level < slog.LevelWarn && !h.sampler.allow() {
+		return nil
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *sampledHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &sampledHandler{Handler: h.Handler.WithAttrs(attrs), sampler: h.sampler}
+}
+
+func (h *sampledHandler) WithGroup(name string) slog.Handler {
+	return &sampledHandler{Handler: h.Handler.WithGroup(name), sampler: h.sampler}
+}
+
+// lazyLogger is a logger derived from the configured one on first use.
+type lazyLogger struct {
+	once   sync.Once
+	logger *slog.Logger
+}
+
+// messageLogger is the logger of the per message informational logs, sampled according to LogSampleEvery and
+// LogSamplePerSecond.
+func (s *SQS) messageLogger() *slog.Logger {
+	if s.config.LogSampleEvery <= 1 && s.config.LogSamplePerSecond == 0 {
+		return s.logger()
+	}
+
+	s.logSampling.once.Do(func() {
+		s.logSampling.logger = slog.New(&sampledHandler{
+			Handler: s.logger().Handler(),
+			sampler: &sampler{every: s.config.LogSampleEvery, perSecond: s.config.LogSamplePerSecond, now: s.now},
+		})
+	})
+	return s.logSampling.logger
+}
@@ -0,0 +1,42 @@
+package consumer
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSampler_allow(t *testing.T) {
+	every := &sampler{every: 3}
+	var got []bool
+	for i := 0; i < 6; i++ {
+		got = append(got, every.allow())
+	}
+	assert.Equal(t, []bool{true, false, false, true, false, false}, got)
+
+	clk := &fakeClock{now: time.Unix(1700000000, 0)}
+	perSecond := &sampler{perSecond: 2, now: clk.Now}
+	assert.True(t, perSecond.allow())
+	assert.True(t, perSecond.allow())
+	assert.False(t, perSecond.allow())
+	<-clk.After(time.Second)
+	assert.True(t, perSecond.allow())
+}
+
+func TestSQS_messageLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	s := &SQS{config: &SQSConf{Logger: logger, LogSampleEvery: 10}}
+
+	for i := 0; i < 20; i++ {
+		s.messageLogger().Info("consumed")
+		s.messageLogger().With("worker", 1).Error("failed")
+	}
+
+	assert.Equal(t, 2, strings.Count(buf.String(), "msg=consumed"))
+	assert.Equal(t, 20, strings.Count(buf.String(), "msg=failed"))
+	assert.Same(t, logger, (&SQS{config: &SQSConf{Logger: logger}}).messageLogger())
+}
@@ -0,0 +1,38 @@
+package consumer
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"hash/fnv"
+)
+
+// ShardByHash assigns a key to the shard given by its FNV-1a hash modulo totalShards.
+func ShardByHash(key string, totalShards, myShard int) bool {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32()%uint32(totalShards)) == myShard
+}
+
+// shard returns the received messages belonging to ShardIndex and makes the other ones visible again.
+func (s *SQS) shard(ctx context.Context, msgs []types.Message) []types.Message {
+	filter := s.config.ShardFilter
+	if filter == nil {
+		filter = ShardByHash
+	}
+
+	mine := make([]types.Message, 0, len(msgs))
+	var others []types.Message
+	for _, msg := range msgs {
+		if filter(s.groupKey(msg), s.config.TotalShards, s.config.ShardIndex) {
+			mine = append(mine, msg)
+		} else {
+			others = append(others, msg)
+		}
+	}
+
+	if len(others) > 0 {
+		s.stats.otherShard.Add(int64(len(others)))
+		s.extendVisibility(ctx, others, 0)
+	}
+	return mine
+}
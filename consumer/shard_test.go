@@ -0,0 +1,66 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestShardByHash(t *testing.T) {
+	for i := range 100 {
+		key := fmt.Sprintf("key%d", i)
+		shards := 0
+		for shard := range 4 {
+			if ShardByHash(key, 4, shard) {
+				shards++
+			}
+		}
+		assert.Equal(t, 1, shards, key)
+	}
+}
+
+func TestSQS_pollCycleShard(t *testing.T) {
+	sqsMock := new(SqsMock)
+	sqsMock.On("ReceiveMessage", mock.Anything, mock.AnythingOfType("*sqs.ReceiveMessageInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+	sqsMock.On("ChangeMessageVisibilityBatch", mock.Anything, mock.AnythingOfType("*sqs.ChangeMessageVisibilityBatchInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+	sqsMock.On("DeleteMessageBatch", mock.Anything, mock.AnythingOfType("*sqs.DeleteMessageBatchInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+
+	s := &SQS{sqs: sqsMock, config: &SQSConf{
+		Queue:          "queue",
+		DeleteStrategy: DeleteStrategyOnSuccess,
+		TotalShards:    2,
+		ShardIndex:     1,
+		ShardFilter: func(key string, totalShards, myShard int) bool {
+			assert.Equal(t, 2, totalShards)
+			return (key == "msg2") == (myShard == 1)
+		},
+	}}
+
+	var consumed []string
+	consumeFn := func(_ context.Context, data []byte, _ map[string]types.MessageAttributeValue) error {
+		consumed = append(consumed, string(data))
+		return nil
+	}
+
+	processed, err := s.pollCycle(context.Background(), s.consumeEach(consumeFn), &worker{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, processed)
+	assert.Equal(t, []string{"msg2"}, consumed)
+	assert.Equal(t, int64(2), s.Stats().OtherShardTotal)
+
+	require.Len(t, sqsMock.visibilityBatchInputs, 1)
+	var released []string
+	for _, entry := range sqsMock.visibilityBatchInputs[0].Entries {
+		released = append(released, aws.ToString(entry.Id))
+		assert.Equal(t, int32(0), entry.VisibilityTimeout)
+	}
+	assert.Equal(t, []string{"msg1", "msg3"}, released)
+}
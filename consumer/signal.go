@@ -0,0 +1,16 @@
+package consumer
+
+import (
+	"os"
+	"syscall"
+)
+
+// DefaultShutdownSignals are the signals stopping the consumer when ShutdownSignals is not set.
+var DefaultShutdownSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+func (c *SQSConf) shutdownSignals() []os.Signal {
+	if len(c.ShutdownSignals) == 0 {
+		return DefaultShutdownSignals
+	}
+	return c.ShutdownSignals
+}
@@ -0,0 +1,47 @@
+package consumer
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"log/slog"
+)
+
+// oversizedBodyAction defaults to DecodeErrorDeadLetter when DeadLetterQueueURL is set, to DecodeErrorDelete otherwise.
+func (c *SQSConf) oversizedBodyAction() DecodeErrorAction {
+	switch {
+	case c.OversizedBodyAction != "":
+		return c.OversizedBodyAction
+	case c.DeadLetterQueueURL != "":
+		return DecodeErrorDeadLetter
+	default:
+		return DecodeErrorDelete
+	}
+}
+
+// oversized reports whether the body of msg exceeds MaxBodyBytes.
+func (s *SQS) oversized(msg types.Message) bool {
+	return s.config.MaxBodyBytes > 0 && len(aws.ToString(msg.Body)) > s.config.MaxBodyBytes
+}
+
+// skipOversized applies OversizedBodyAction to a message whose body exceeds MaxBodyBytes and reports whether the
+// message must be deleted.
+func (s *SQS) skipOversized(ctx context.Context, msg types.Message) bool {
+	return s.skip(ctx, msg, s.config.oversizedBodyAction(), "skipping message exceeding MaxBodyBytes",
+		slog.Int("size", len(aws.ToString(msg.Body))))
+}
+
+// skip applies action to a message left out of consumption and reports whether the message must be deleted.
+func (s *SQS) skip(ctx context.Context, msg types.Message, action DecodeErrorAction, reason string, attrs ...any) bool {
+	attrs = append(attrs, slog.String("messageId", aws.ToString(msg.MessageId)), slog.String("action", string(action)))
+	s.logger().Warn(reason, attrs...)
+
+	switch action {
+	case DecodeErrorDeadLetter:
+		return s.applyResult(ctx, msg, Result{ToDLQ: true})
+	case DecodeErrorDelete:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,57 @@
+package consumer
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func TestSQS_consumeMessagesMaxBodyBytes(t *testing.T) {
+	tests := []struct {
+		name       string
+		conf       SQSConf
+		wantDelete []string
+		wantSent   int
+	}{
+		{name: "shouldDeleteByDefault", wantDelete: []string{"big", "small"}},
+		{name: "shouldKeep", conf: SQSConf{OversizedBodyAction: DecodeErrorKeep}, wantDelete: []string{"small"}},
+		{name: "shouldDeadLetter", conf: SQSConf{DeadLetterQueueURL: "dlq"}, wantDelete: []string{"big", "small"}, wantSent: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqsMock := new(SqsMock)
+			sqsMock.On("SendMessage", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+
+			conf := tt.conf
+			conf.Queue = "queue"
+			conf.DeleteStrategy = DeleteStrategyOnSuccess
+			conf.MaxBodyBytes = 8
+			s := &SQS{sqs: sqsMock, config: &conf}
+
+			var consumed []string
+			consumeFn := func(_ context.Context, data []byte, _ map[string]types.MessageAttributeValue) error {
+				consumed = append(consumed, string(data))
+				return nil
+			}
+
+			messages := []types.Message{
+				{MessageId: aws.String("big"), Body: aws.String("too large body")},
+				{MessageId: aws.String("small"), Body: aws.String("small")},
+			}
+			toDelete, _ := s.consumeMessages(context.Background(), &worker{}, messages, s.consumeEach(consumeFn))
+
+			ids := make([]string, 0, len(toDelete))
+			for _, msg := range toDelete {
+				ids = append(ids, aws.ToString(msg.MessageId))
+			}
+			assert.Equal(t, tt.wantDelete, ids)
+			assert.Equal(t, []string{"small"}, consumed)
+			assert.Len(t, sqsMock.sendInputs, tt.wantSent)
+			assert.Equal(t, int64(1), s.Stats().OversizedTotal)
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package consumer
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"log/slog"
+	"time"
+)
+
+// clockSkewWarnInterval is the minimum time between two clock skew warnings.
+const clockSkewWarnInterval = time.Minute
+
+// clockSkew estimates how far the SQS clock is ahead of the local one from a received message. The first receive
+// of a message happens now on the SQS clock, bounding the skew both ways, while the SentTimestamp of a message
+// only shows a local clock running behind. It returns false when msg gives no estimate.
+func clockSkew(msg types.Message, now time.Time) (time.Duration, bool) {
+	m := newMessage(msg, now)
+	if m.ApproximateReceiveCount == 1 && !m.FirstReceiveTimestamp.IsZero() {
+		return m.FirstReceiveTimestamp.Sub(now), true
+	}
+	if m.SentTimestamp.After(now) {
+		return m.SentTimestamp.Sub(now), true
+	}
+	return 0, false
+}
+
+// checkClockSkew warns, at most every clockSkewWarnInterval, when the received messages show a clock skew beyond
+// ClockSkewTolerance.
+func (s *SQS) checkClockSkew(msgs []types.Message) {
+	now := s.now()
+
+	var worst time.Duration
+	var messageID string
+	for _, msg := range msgs {
+		if skew, ok := clockSkew(msg, now); ok && skew.Abs() > worst.Abs() {
+			worst, messageID = skew, aws.ToString(msg.MessageId)
+		}
+	}
+	if worst.Abs() <= s.config.ClockSkewTolerance {
+		return
+	}
+
+	last := s.skewWarned.Load()
+	if last != 0 && now.Sub(time.Unix(0, last)) < clockSkewWarnInterval {
+		return
+	}
+	if !s.skewWarned.CompareAndSwap(last, now.UnixNano()) {
+		return
+	}
+
+	s.logger().Warn("local clock skewed from the SQS one beyond ClockSkewTolerance, message ages and expiries are off",
+		slog.Duration("sqsClockAhead", worst),
+		slog.Duration("tolerance", s.config.ClockSkewTolerance),
+		slog.String("messageId", messageID))
+}
@@ -0,0 +1,89 @@
+package consumer
+
+import (
+	"bytes"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func skewedMessage(id string, sent, firstReceive time.Time, receiveCount int) types.Message {
+	return types.Message{MessageId: aws.String(id), Attributes: map[string]string{
+		"SentTimestamp":                    strconv.FormatInt(sent.UnixMilli(), 10),
+		"ApproximateFirstReceiveTimestamp": strconv.FormatInt(firstReceive.UnixMilli(), 10),
+		"ApproximateReceiveCount":          strconv.Itoa(receiveCount),
+	}}
+}
+
+func TestClockSkew(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name   string
+		msg    types.Message
+		want   time.Duration
+		wantOk bool
+	}{
+		{
+			name:   "shouldUseFirstReceiveOfFirstDelivery",
+			msg:    skewedMessage("1", now.Add(-time.Hour), now.Add(-5*time.Second), 1),
+			want:   -5 * time.Second,
+			wantOk: true,
+		},
+		{
+			name:   "shouldUseSentTimestampInTheFuture",
+			msg:    skewedMessage("2", now.Add(3*time.Second), now.Add(-time.Hour), 2),
+			want:   3 * time.Second,
+			wantOk: true,
+		},
+		{
+			name: "shouldNotEstimateFromRedeliveredPastMessage",
+			msg:  skewedMessage("3", now.Add(-time.Hour), now.Add(-time.Hour), 2),
+		},
+		{
+			name: "shouldNotEstimateWithoutAttributes",
+			msg:  types.Message{MessageId: aws.String("4")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := clockSkew(tt.msg, now)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSQS_checkClockSkew(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	clk := &fakeClock{now: now}
+
+	var buf bytes.Buffer
+	s := &SQS{
+		config: &SQSConf{ClockSkewTolerance: time.Second, Logger: slog.New(slog.NewTextHandler(&buf, nil))},
+		clock:  clk,
+	}
+
+	s.checkClockSkew([]types.Message{skewedMessage("within", now, now.Add(500*time.Millisecond), 1)})
+	assert.Empty(t, buf.String())
+
+	skewed := []types.Message{
+		skewedMessage("small", now, now.Add(2*time.Second), 1),
+		skewedMessage("large", now, now.Add(-10*time.Second), 1),
+	}
+	s.checkClockSkew(skewed)
+	s.checkClockSkew(skewed)
+	assert.Equal(t, 1, strings.Count(buf.String(), "level=WARN"))
+	assert.Contains(t, buf.String(), "sqsClockAhead=-10s")
+	assert.Contains(t, buf.String(), "messageId=large")
+
+	clk.now = now.Add(clockSkewWarnInterval)
+	s.checkClockSkew(skewed)
+	assert.Equal(t, 2, strings.Count(buf.String(), "level=WARN"))
+}
@@ -0,0 +1,32 @@
+package consumer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// splitJSONArray adapts consumeFn to the bodies holding a JSON array of logical messages, calling it for every
+// element in turn with the attributes of the message. It returns the error of the first element failing, the
+// following elements being left to the redelivery of the whole message. Other bodies are passed as is.
+func splitJSONArray(consumeFn ContextConsumerFn) ContextConsumerFn {
+	return func(ctx context.Context, data []byte, attributes map[string]types.MessageAttributeValue) error {
+		if trimmed := bytes.TrimSpace(data); len(trimmed) == 0 || trimmed[0] != '[' {
+			return consumeFn(ctx, data, attributes)
+		}
+
+		var elements []json.RawMessage
+		if err := json.Unmarshal(data, &elements); err != nil {
+			return &DecodeError{Err: err}
+		}
+
+		for i, element := range elements {
+			if err := consumeFn(ctx, element, attributes); err != nil {
+				return fmt.Errorf("array element %d of %d: %w", i, len(elements), err)
+			}
+		}
+		return nil
+	}
+}
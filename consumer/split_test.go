@@ -0,0 +1,62 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSQS_consumeMessagesSplitJSONArray(t *testing.T) {
+	messages := []types.Message{
+		{MessageId: aws.String("array"), Body: aws.String(` [{"id":1}, {"id":2}]`)},
+		{MessageId: aws.String("failing"), Body: aws.String(`[{"id":3}, "fail", {"id":4}]`)},
+		{MessageId: aws.String("object"), Body: aws.String(`{"id":5}`)},
+		{MessageId: aws.String("invalid"), Body: aws.String(`[{"id":6}`)},
+	}
+
+	tests := []struct {
+		name         string
+		split        bool
+		wantConsumed []string
+		wantDelete   []string
+	}{
+		{
+			name:         "shouldSplitArrays",
+			split:        true,
+			wantConsumed: []string{`{"id":1}`, `{"id":2}`, `{"id":3}`, `"fail"`, `{"id":5}`},
+			wantDelete:   []string{"array", "object", "invalid"},
+		},
+		{
+			name:         "shouldConsumeArraysWhole",
+			wantConsumed: []string{` [{"id":1}, {"id":2}]`, `[{"id":3}, "fail", {"id":4}]`, `{"id":5}`, `[{"id":6}`},
+			wantDelete:   []string{"array", "failing", "object", "invalid"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &SQS{config: &SQSConf{Queue: "queue", DeleteStrategy: DeleteStrategyOnSuccess, SplitJSONArray: tt.split}}
+
+			var consumed []string
+			consumeFn := func(_ context.Context, data []byte, _ map[string]types.MessageAttributeValue) error {
+				consumed = append(consumed, string(data))
+				if string(data) == `"fail"` {
+					return errors.New("fake consume error")
+				}
+				return nil
+			}
+
+			toDelete, _ := s.consumeMessages(context.Background(), &worker{}, messages, s.consumeEach(consumeFn))
+
+			ids := make([]string, len(toDelete))
+			for i, msg := range toDelete {
+				ids[i] = aws.ToString(msg.MessageId)
+			}
+			assert.Equal(t, tt.wantConsumed, consumed)
+			assert.Equal(t, tt.wantDelete, ids)
+		})
+	}
+}
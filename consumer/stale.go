@@ -0,0 +1,31 @@
+package consumer
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"log/slog"
+	"time"
+)
+
+// stale reports whether msg was sent before MinSentTimestamp. Messages without SentTimestamp are never stale.
+func (s *SQS) stale(msg types.Message) bool {
+	if s.config.MinSentTimestamp.IsZero() {
+		return false
+	}
+
+	sent, ok := sentTimestamp(msg)
+	return ok && sent.Before(s.config.MinSentTimestamp)
+}
+
+// skipStale applies StaleMessageAction, DecodeErrorDelete by default, to a message sent before MinSentTimestamp
+// and reports whether the message must be deleted.
+func (s *SQS) skipStale(ctx context.Context, msg types.Message) bool {
+	action := s.config.StaleMessageAction
+	if action == "" {
+		action = DecodeErrorDelete
+	}
+
+	sent, _ := sentTimestamp(msg)
+	return s.skip(ctx, msg, action, "skipping message sent before MinSentTimestamp",
+		slog.String("sentTimestamp", sent.Format(time.RFC3339)))
+}
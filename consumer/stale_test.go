@@ -0,0 +1,53 @@
+package consumer
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestSQS_consumeMessagesMinSentTimestamp(t *testing.T) {
+	tests := []struct {
+		name       string
+		action     DecodeErrorAction
+		wantDelete []string
+	}{
+		{name: "shouldDeleteByDefault", wantDelete: []string{"old", "new", "unknown"}},
+		{name: "shouldKeep", action: DecodeErrorKeep, wantDelete: []string{"new", "unknown"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &SQS{config: &SQSConf{
+				Queue:              "queue",
+				DeleteStrategy:     DeleteStrategyOnSuccess,
+				MinSentTimestamp:   time.UnixMilli(1700000000000),
+				StaleMessageAction: tt.action,
+			}}
+
+			var consumed []string
+			consumeFn := func(_ context.Context, data []byte, _ map[string]types.MessageAttributeValue) error {
+				consumed = append(consumed, string(data))
+				return nil
+			}
+
+			messages := []types.Message{
+				{MessageId: aws.String("old"), Body: aws.String("old"), Attributes: map[string]string{"SentTimestamp": "1699999999999"}},
+				{MessageId: aws.String("new"), Body: aws.String("new"), Attributes: map[string]string{"SentTimestamp": "1700000000000"}},
+				{MessageId: aws.String("unknown"), Body: aws.String("unknown")},
+			}
+			toDelete, _ := s.consumeMessages(context.Background(), &worker{}, messages, s.consumeEach(consumeFn))
+
+			ids := make([]string, 0, len(toDelete))
+			for _, msg := range toDelete {
+				ids = append(ids, aws.ToString(msg.MessageId))
+			}
+			assert.Equal(t, tt.wantDelete, ids)
+			assert.Equal(t, []string{"new", "unknown"}, consumed)
+			assert.Equal(t, int64(1), s.Stats().StaleTotal)
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package consumer
+
+import (
+	"log/slog"
+	"net/url"
+)
+
+// features returns the names of the optional features the configuration enables, in declaration order.
+func (c *SQSConf) features() []string {
+	var enabled []string
+	for _, f := range []struct {
+		name string
+		on   bool
+	}{
+		{"VerifyQueue", c.VerifyQueue},
+		{"CredentialsProvider", c.CredentialsProvider != nil},
+		{"ContainerCredentials", c.ContainerCredentials},
+		{"APIOptions", len(c.APIOptions) > 0},
+		{"AdaptiveBatchSize", c.AdaptiveBatchSize},
+		{"AdaptiveWaitTime", c.AdaptiveWaitTime},
+		{"InitialVisibilityExtension", c.InitialVisibilityExtension > 0},
+		{"VisibilityHeartbeat", c.VisibilityHeartbeat > 0},
+		{"VisibilityDeadline", c.VisibilityDeadline},
+		{"MaxHandlerRetryDuration", c.MaxHandlerRetryDuration > 0},
+		{"RetryBudget", c.RetryBudgetRate > 0},
+		{"MinPollInterval", c.MinPollInterval > 0},
+		{"UnderDeliveryWindow", c.UnderDeliveryWindow > 0},
+		{"OldestMessageAge", c.OldestMessageAgeInterval > 0},
+		{"QueueDepth", c.QueueDepthInterval > 0},
+		{"TTLAttribute", c.TTLAttribute != ""},
+		{"MinSentTimestamp", !c.MinSentTimestamp.IsZero()},
+		{"SplitJSONArray", c.SplitJSONArray},
+		{"Filter", c.Filter != nil},
+		{"ProcessDecider", c.ProcessDecider != nil},
+		{"BatchSorter", c.BatchSorter != nil},
+		{"ErrorClassifier", c.ErrorClassifier != nil},
+		{"ErrorVisibilityTimeout", c.ErrorVisibilityTimeout != nil},
+		{"Deduplication", c.DedupWindow > 0 || c.IdempotencyStore != nil},
+		{"CircuitBreaker", c.circuitBreaker()},
+		{"FIFO", c.FIFO},
+		{"PartitionByGroup", c.PartitionByGroup},
+		{"Sharding", c.TotalShards > 1},
+		{"ContinueOnDeleteError", c.ContinueOnDeleteError},
+		{"DeleteRetryBuffer", c.DeleteRetryBufferSize > 0},
+		{"StreamBatches", c.StreamBatches},
+		{"MaxInFlight", c.MaxInFlight > 0},
+		{"MaxInFlightPerGroup", c.MaxInFlightPerGroup > 0},
+		{"GroupRateLimit", c.GroupRateLimit > 0},
+		{"ConsumeConcurrently", c.ConsumeConcurrently},
+		{"SignalHandling", !c.DisableSignalHandling},
+		{"ExtendedClient", c.ExtendedClient},
+		{"DecompressBodies", c.DecompressBodies},
+		{"BodyCharset", c.BodyCharset != nil},
+		{"UnwrapEventBridge", c.UnwrapEventBridge},
+		{"SlowHandlerThreshold", c.SlowHandlerThreshold > 0},
+		{"PanicThreshold", c.PanicThreshold > 0},
+		{"PanicsAreFatal", c.PanicsAreFatal},
+		{"DeadLetterQueue", c.DeadLetterQueueURL != "" || c.DiscoverDeadLetterQueue},
+		{"MaxBodyBytes", c.MaxBodyBytes > 0},
+		{"Tracing", c.ShouldTrace != nil},
+		{"Metrics", c.Metrics != nil},
+		{"Audit", c.AuditWriter != nil},
+		{"LogSampling", c.LogSampleEvery > 1 || c.LogSamplePerSecond > 0},
+	} {
+		if f.on {
+			enabled = append(enabled, f.name)
+		}
+	}
+	return enabled
+}
+
+// logConfig logs the effective configuration when the consumer starts. Only settings are logged: endpoint
+// credentials are redacted and clients, callbacks and handler dependencies are reported as enabled features.
+func (s *SQS) logConfig() {
+	attrs := []any{
+		slog.String("queue", s.config.Queue),
+		slog.Int("concurrency", s.config.Concurrency),
+		slog.String("deleteStrategy", string(s.DeleteStrategy())),
+		slog.Int("maxNumberOfMessages", int(s.config.MaxNumberOfMessages)),
+		slog.Int("waitTimeSeconds", int(s.config.WaitTimeSeconds)),
+		// zero is the visibility timeout of the queue
+		slog.Int("visibilityTimeoutSeconds", int(s.config.VisibilityTimeout)),
+		slog.String("decodeErrorAction", string(s.config.decodeErrorAction())),
+		slog.Any("features", s.config.features()),
+	}
+
+	if s.config.EndpointURL != "" {
+		endpoint := s.config.EndpointURL
+		if u, err := url.Parse(endpoint); err == nil {
+			endpoint = u.Redacted()
+		}
+		attrs = append(attrs, slog.String("endpoint", endpoint))
+	}
+
+	s.logger().Info("consumer starting", attrs...)
+}
@@ -0,0 +1,108 @@
+package consumer
+
+import (
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a point in time snapshot of the consumer counters.
+type Stats struct {
+	ReceivedTotal       int64
+	ProcessedTotal      int64
+	FailedTotal         int64
+	DeletedTotal        int64
+	DeleteFailedTotal   int64
+	ExpiredTotal        int64
+	FilteredTotal       int64
+	DuplicateTotal      int64
+	OversizedTotal      int64
+	StaleTotal          int64
+	OtherShardTotal     int64
+	UnderDeliveredTotal int64
+	DeclinedTotal       int64
+	// RetryBudgetTokens is the number of in-process retries the retry budget allows right away.
+	RetryBudgetTokens         float64
+	RetryBudgetExhaustedTotal int64
+	// PollerBlockedTotal counts the waits of the workers for a free partition or MaxInFlight slot.
+	PollerBlockedTotal    int64
+	GroupSaturatedTotal   int64
+	GroupRateLimitedTotal int64
+	Uptime                time.Duration
+	CircuitState          CircuitState
+}
+
+type stats struct {
+	started              atomic.Int64
+	received             atomic.Int64
+	processed            atomic.Int64
+	failed               atomic.Int64
+	deleted              atomic.Int64
+	deleteFailed         atomic.Int64
+	expired              atomic.Int64
+	filtered             atomic.Int64
+	duplicates           atomic.Int64
+	oversized            atomic.Int64
+	stale                atomic.Int64
+	otherShard           atomic.Int64
+	underDelivered       atomic.Int64
+	declined             atomic.Int64
+	retryBudgetExhausted atomic.Int64
+	pollerBlocked        atomic.Int64
+	groupSaturated       atomic.Int64
+	groupRateLimited     atomic.Int64
+}
+
+func (s *SQS) Stats() Stats {
+	st := Stats{
+		ReceivedTotal:             s.stats.received.Load(),
+		ProcessedTotal:            s.stats.processed.Load(),
+		FailedTotal:               s.stats.failed.Load(),
+		DeletedTotal:              s.stats.deleted.Load(),
+		DeleteFailedTotal:         s.stats.deleteFailed.Load(),
+		ExpiredTotal:              s.stats.expired.Load(),
+		FilteredTotal:             s.stats.filtered.Load(),
+		DuplicateTotal:            s.stats.duplicates.Load(),
+		OversizedTotal:            s.stats.oversized.Load(),
+		StaleTotal:                s.stats.stale.Load(),
+		OtherShardTotal:           s.stats.otherShard.Load(),
+		UnderDeliveredTotal:       s.stats.underDelivered.Load(),
+		DeclinedTotal:             s.stats.declined.Load(),
+		RetryBudgetTokens:         s.retryBudgetTokens(),
+		RetryBudgetExhaustedTotal: s.stats.retryBudgetExhausted.Load(),
+		PollerBlockedTotal:        s.stats.pollerBlocked.Load(),
+		GroupSaturatedTotal:       s.stats.groupSaturated.Load(),
+		GroupRateLimitedTotal:     s.stats.groupRateLimited.Load(),
+		CircuitState:              s.breaker.current(),
+	}
+
+	if started := s.stats.started.Load(); started != 0 {
+		st.Uptime = s.since(time.Unix(0, started))
+	}
+
+	return st
+}
+
+func (s *SQS) logStats() {
+	st := s.Stats()
+	s.logger().Info("consumer stopped",
+		slog.Int64("received", st.ReceivedTotal),
+		slog.Int64("processed", st.ProcessedTotal),
+		slog.Int64("failed", st.FailedTotal),
+		slog.Int64("deleted", st.DeletedTotal),
+		slog.Int64("deleteFailed", st.DeleteFailedTotal),
+		slog.Int64("expired", st.ExpiredTotal),
+		slog.Int64("filtered", st.FilteredTotal),
+		slog.Int64("duplicates", st.DuplicateTotal),
+		slog.Int64("oversized", st.OversizedTotal),
+		slog.Int64("stale", st.StaleTotal),
+		slog.Int64("otherShard", st.OtherShardTotal),
+		slog.Int64("underDelivered", st.UnderDeliveredTotal),
+		slog.Int64("declined", st.DeclinedTotal),
+		slog.Int64("retryBudgetExhausted", st.RetryBudgetExhaustedTotal),
+		slog.Int64("pollerBlocked", st.PollerBlockedTotal),
+		slog.Int64("groupSaturated", st.GroupSaturatedTotal),
+		slog.Int64("groupRateLimited", st.GroupRateLimitedTotal),
+		slog.Duration("uptime", st.Uptime),
+	)
+}
@@ -0,0 +1,27 @@
+package consumer
+
+// DeleteStrategy returns the delete strategy in effect, the one set by SetDeleteStrategy if any.
+func (s *SQS) DeleteStrategy() DeleteStrategy {
+	if strategy := s.deleteStrategy.Load(); strategy != nil {
+		return *strategy
+	}
+	return s.config.DeleteStrategy
+}
+
+// SetDeleteStrategy overrides the DeleteStrategy of the running consumer, for instance to stop deleting messages
+// during an incident. It is safe to call concurrently with running workers, which read it at the start of every
+// poll cycle: the batches being consumed when it is called are acknowledged according to the previous strategy.
+// Switching away from DeleteStrategyBatched leaves the buffered acknowledgements pending until Flush is called or
+// Start returns. Pipe and StartWithResult rely on acknowledgements and must not be switched to
+// DeleteStrategyImmediate or DeleteStrategyNever, StartWithAck must stay on DeleteStrategyNever.
+func (s *SQS) SetDeleteStrategy(strategy DeleteStrategy) {
+	s.deleteStrategy.Store(&strategy)
+}
+
+// strategy returns the DeleteStrategy of the poll cycle of w.
+func (s *SQS) strategy(w *worker) DeleteStrategy {
+	if w.strategy != "" {
+		return w.strategy
+	}
+	return s.DeleteStrategy()
+}
@@ -0,0 +1,40 @@
+package consumer
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSQS_SetDeleteStrategy(t *testing.T) {
+	sqsMock := new(SqsMock)
+	sqsMock.On("ReceiveMessage", mock.Anything, mock.AnythingOfType("*sqs.ReceiveMessageInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+	sqsMock.On("DeleteMessageBatch", mock.Anything, mock.AnythingOfType("*sqs.DeleteMessageBatchInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+
+	s := &SQS{sqs: sqsMock, config: &SQSConf{Queue: "queue", DeleteStrategy: DeleteStrategyImmediate}}
+	assert.Equal(t, DeleteStrategyImmediate, s.DeleteStrategy())
+
+	consumeFn := s.consumeEach(func(_ context.Context, _ []byte, _ map[string]types.MessageAttributeValue) error {
+		// switching during a cycle doesn't apply to the batch being consumed
+		s.SetDeleteStrategy(DeleteStrategyOnSuccess)
+		return nil
+	})
+
+	w := &worker{}
+	_, err := s.pollCycle(context.Background(), consumeFn, w)
+	require.NoError(t, err)
+	require.Len(t, sqsMock.deleteInputs, 1, "deleted on receipt only")
+	assert.Equal(t, DeleteStrategyOnSuccess, s.DeleteStrategy())
+	assert.Equal(t, DeleteStrategyImmediate, s.config.DeleteStrategy)
+
+	s.SetDeleteStrategy(DeleteStrategyOnSuccess)
+	_, err = s.pollCycle(context.Background(), consumeFn, w)
+	require.NoError(t, err)
+	require.Len(t, sqsMock.deleteInputs, 2, "deleted once consumed")
+	assert.Equal(t, DeleteStrategyOnSuccess, w.strategy)
+}
@@ -0,0 +1,56 @@
+package consumer
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+)
+
+type traceKey struct{}
+
+// Traced reports whether ShouldTrace selected the message a ContextConsumerFn is called for, so that the consumer
+// function can record its own detailed logs or spans for it.
+func Traced(ctx context.Context) bool {
+	traced, _ := ctx.Value(traceKey{}).(bool)
+	return traced
+}
+
+// shouldTrace returns the message context marked as traced when ShouldTrace selects the message.
+func (s *SQS) shouldTrace(ctx context.Context, msg Message) (context.Context, bool) {
+	if s.config.ShouldTrace == nil || !s.config.ShouldTrace(msg) {
+		return ctx, false
+	}
+
+	s.trace(ctx, "consuming traced message",
+		slog.String("messageId", aws.ToString(msg.MessageId)),
+		slog.String("messageGroupId", msg.MessageGroupID),
+		slog.Int("receiveCount", msg.ApproximateReceiveCount),
+		slog.Time("sentTimestamp", msg.SentTimestamp),
+		slog.Any("attributes", slices.Sorted(maps.Keys(msg.MessageAttributes))),
+		slog.Int("bodySize", len(aws.ToString(msg.Body))),
+	)
+
+	return context.WithValue(ctx, traceKey{}, true), true
+}
+
+func (s *SQS) traceConsumed(ctx context.Context, msg Message, elapsed time.Duration, err error) {
+	attrs := []slog.Attr{
+		slog.String("messageId", aws.ToString(msg.MessageId)),
+		slog.Duration("duration", elapsed),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err.Error()))
+	}
+
+	s.trace(ctx, "consumed traced message", attrs...)
+}
+
+// trace logs at the info level even when the logger is set to a higher level.
+func (s *SQS) trace(ctx context.Context, msg string, attrs ...slog.Attr) {
+	r := slog.NewRecord(s.now(), slog.LevelInfo, msg, 0)
+	r.AddAttrs(attrs...)
+	_ = s.logger().Handler().Handle(ctx, r)
+}
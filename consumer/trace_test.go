@@ -0,0 +1,36 @@
+package consumer
+
+import (
+	"bytes"
+	"context"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSQS_ShouldTrace(t *testing.T) {
+	var buf bytes.Buffer
+	s := &SQS{config: &SQSConf{
+		Queue:          "queue",
+		DeleteStrategy: DeleteStrategyOnSuccess,
+		Logger:         slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelError})),
+		ShouldTrace: func(msg Message) bool {
+			_, ok := msg.MessageAttributes["attribute2"]
+			return ok
+		},
+	}}
+
+	traced := make(map[string]bool)
+	consumeFn := func(ctx context.Context, data []byte, attributes map[string]types.MessageAttributeValue) error {
+		traced[string(data)] = Traced(ctx)
+		return nil
+	}
+
+	s.consumeMessages(context.Background(), &worker{}, getQueueContent().Messages, s.consumeEach(consumeFn))
+
+	assert.Equal(t, map[string]bool{"msg1": false, "msg2": true, "msg3": false}, traced)
+	assert.Equal(t, 2, strings.Count(buf.String(), "messageId=msg2"))
+	assert.NotContains(t, buf.String(), "messageId=msg1")
+}
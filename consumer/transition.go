@@ -0,0 +1,53 @@
+package consumer
+
+import (
+	"sync"
+)
+
+const (
+	queueUnknown = queueState(iota)
+	queueEmpty
+	queueNonEmpty
+)
+
+type queueState int
+
+// transitions tracks whether the queue has work from the receives of all the workers: the queue becomes empty
+// once every worker received nothing, and non-empty as soon as one worker receives messages.
+type transitions struct {
+	mu          sync.Mutex
+	emptyWorker int
+	state       queueState
+}
+
+func (s *SQS) observeReceive(w *worker, empty bool) {
+	if s.config.OnQueueEmpty == nil && s.config.OnQueueNonEmpty == nil {
+		return
+	}
+
+	t := &s.transitions
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if empty != w.empty {
+		w.empty = empty
+		if empty {
+			t.emptyWorker++
+		} else {
+			t.emptyWorker--
+		}
+	}
+
+	switch {
+	case !empty && t.state != queueNonEmpty:
+		t.state = queueNonEmpty
+		if s.config.OnQueueNonEmpty != nil {
+			s.config.OnQueueNonEmpty()
+		}
+	case t.emptyWorker >= s.config.Concurrency && t.state != queueEmpty:
+		t.state = queueEmpty
+		if s.config.OnQueueEmpty != nil {
+			s.config.OnQueueEmpty()
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package consumer
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSQS_observeReceive(t *testing.T) {
+	var events []string
+	s := &SQS{config: &SQSConf{
+		Concurrency:     2,
+		OnQueueEmpty:    func() { events = append(events, "empty") },
+		OnQueueNonEmpty: func() { events = append(events, "nonEmpty") },
+	}}
+	w1, w2 := &worker{}, &worker{}
+
+	s.observeReceive(w1, false)
+	s.observeReceive(w2, false)
+	assert.Equal(t, []string{"nonEmpty"}, events)
+
+	s.observeReceive(w1, true)
+	s.observeReceive(w1, true)
+	assert.Equal(t, []string{"nonEmpty"}, events, "one worker receiving nothing doesn't make the queue empty")
+
+	s.observeReceive(w2, true)
+	s.observeReceive(w2, true)
+	assert.Equal(t, []string{"nonEmpty", "empty"}, events)
+
+	s.observeReceive(w1, false)
+	s.observeReceive(w2, false)
+	assert.Equal(t, []string{"nonEmpty", "empty", "nonEmpty"}, events)
+}
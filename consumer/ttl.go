@@ -0,0 +1,49 @@
+package consumer
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"strconv"
+	"time"
+)
+
+// expired reports whether the TTLAttribute of msg indicates it must be dropped without processing, past
+// ClockSkewTolerance.
+func (s *SQS) expired(msg types.Message) bool {
+	if s.config.TTLAttribute == "" {
+		return false
+	}
+
+	attr, ok := msg.MessageAttributes[s.config.TTLAttribute]
+	if !ok || attr.StringValue == nil {
+		return false
+	}
+
+	expiresAt, ok := parseExpiry(*attr.StringValue, msg)
+	if !ok {
+		return false
+	}
+
+	return s.now().After(expiresAt.Add(s.config.ClockSkewTolerance))
+}
+
+// parseExpiry reads a relative duration ("90s", "15m") counted from the SentTimestamp of msg,
+// an RFC 3339 timestamp or a Unix timestamp in seconds.
+func parseExpiry(value string, msg types.Message) (time.Time, bool) {
+	if d, err := time.ParseDuration(value); err == nil {
+		sent, ok := sentTimestamp(msg)
+		if !ok {
+			return time.Time{}, false
+		}
+		return sent.Add(d), true
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, true
+	}
+
+	if sec, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(sec, 0), true
+	}
+
+	return time.Time{}, false
+}
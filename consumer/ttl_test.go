@@ -0,0 +1,56 @@
+package consumer
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSQS_expired(t *testing.T) {
+	sent := strconv.FormatInt(time.Now().Add(-time.Minute).UnixMilli(), 10)
+
+	tests := []struct {
+		name string
+		ttl  *string
+		want bool
+	}{
+		{name: "relativeExpired", ttl: aws.String("30s"), want: true},
+		{name: "relativeValid", ttl: aws.String("1h"), want: false},
+		{name: "absoluteExpired", ttl: aws.String(time.Now().Add(-time.Second).Format(time.RFC3339)), want: true},
+		{name: "absoluteValid", ttl: aws.String(time.Now().Add(time.Hour).Format(time.RFC3339)), want: false},
+		{name: "unixExpired", ttl: aws.String(strconv.FormatInt(time.Now().Add(-time.Second).Unix(), 10)), want: true},
+		{name: "unparsable", ttl: aws.String("tomorrow"), want: false},
+		{name: "missing", ttl: nil, want: false},
+	}
+
+	s := &SQS{config: &SQSConf{TTLAttribute: "ttl"}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := types.Message{
+				Attributes:        map[string]string{"SentTimestamp": sent},
+				MessageAttributes: map[string]types.MessageAttributeValue{},
+			}
+			if tt.ttl != nil {
+				msg.MessageAttributes["ttl"] = types.MessageAttributeValue{DataType: aws.String("String"), StringValue: tt.ttl}
+			}
+			assert.Equal(t, tt.want, s.expired(msg))
+		})
+	}
+}
+
+func TestSQS_expiredClockSkewTolerance(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	msg := types.Message{MessageAttributes: map[string]types.MessageAttributeValue{
+		"ttl": {DataType: aws.String("String"), StringValue: aws.String(strconv.FormatInt(now.Add(-time.Second).Unix(), 10))},
+	}}
+
+	s := &SQS{config: &SQSConf{TTLAttribute: "ttl", ClockSkewTolerance: 2 * time.Second}, clock: &fakeClock{now: now}}
+	assert.False(t, s.expired(msg))
+
+	s.config.ClockSkewTolerance = 500 * time.Millisecond
+	assert.True(t, s.expired(msg))
+}
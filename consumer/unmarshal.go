@@ -0,0 +1,84 @@
+package consumer
+
+import (
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"reflect"
+	"strconv"
+)
+
+// attributeTag names the message attribute a struct field is read from by UnmarshalAttributes.
+const attributeTag = "sqsattr"
+
+// UnmarshalAttributes sets the fields of the struct v points to from the String and Number message attributes
+// named by their `sqsattr` tag. Fields can be strings, booleans, integers or floats; untagged fields and fields
+// whose attribute is missing are left untouched.
+//
+//	type Headers struct {
+//		Type    string `sqsattr:"messageType"`
+//		Version int    `sqsattr:"version"`
+//	}
+func UnmarshalAttributes(attributes map[string]types.MessageAttributeValue, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return SentinelErrorInvalidUnmarshalTarget
+	}
+
+	rv = rv.Elem()
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		name, ok := field.Tag.Lookup(attributeTag)
+		if !ok || name == "-" || !field.IsExported() {
+			continue
+		}
+
+		attr, ok := attributes[name]
+		if !ok || attr.StringValue == nil {
+			continue
+		}
+
+		if err := setField(rv.Field(i), aws.ToString(attr.StringValue)); err != nil {
+			return fmt.Errorf("attribute %s into field %s: %w", name, field.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func setField(f reflect.Value, value string) error {
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		f.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(value, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported field type %s", f.Type())
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package consumer
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestUnmarshalAttributes(t *testing.T) {
+	type headers struct {
+		Type     string  `sqsattr:"messageType"`
+		Version  int     `sqsattr:"version"`
+		Retries  uint8   `sqsattr:"retries"`
+		Priority float64 `sqsattr:"priority"`
+		Urgent   bool    `sqsattr:"urgent"`
+		Missing  string  `sqsattr:"missing"`
+		Untagged string
+	}
+
+	attributes := map[string]types.MessageAttributeValue{
+		"messageType": {DataType: aws.String("String"), StringValue: aws.String("order.created")},
+		"version":     {DataType: aws.String("Number"), StringValue: aws.String("3")},
+		"retries":     {DataType: aws.String("Number"), StringValue: aws.String("2")},
+		"priority":    {DataType: aws.String("Number"), StringValue: aws.String("0.5")},
+		"urgent":      {DataType: aws.String("String"), StringValue: aws.String("true")},
+		"Untagged":    {DataType: aws.String("String"), StringValue: aws.String("ignored")},
+	}
+
+	v := headers{Missing: "default"}
+	require.NoError(t, UnmarshalAttributes(attributes, &v))
+	assert.Equal(t, headers{
+		Type:     "order.created",
+		Version:  3,
+		Retries:  2,
+		Priority: 0.5,
+		Urgent:   true,
+		Missing:  "default",
+	}, v)
+}
+
+func TestUnmarshalAttributesErrors(t *testing.T) {
+	var v struct {
+		Version int `sqsattr:"version"`
+	}
+
+	assert.ErrorIs(t, UnmarshalAttributes(nil, v), SentinelErrorInvalidUnmarshalTarget)
+	assert.ErrorIs(t, UnmarshalAttributes(nil, new(string)), SentinelErrorInvalidUnmarshalTarget)
+
+	err := UnmarshalAttributes(map[string]types.MessageAttributeValue{
+		"version": {DataType: aws.String("Number"), StringValue: aws.String("v3")},
+	}, &v)
+	assert.ErrorContains(t, err, "attribute version into field Version")
+
+	var unsupported struct {
+		Tags []string `sqsattr:"tags"`
+	}
+	err = UnmarshalAttributes(map[string]types.MessageAttributeValue{
+		"tags": {DataType: aws.String("String"), StringValue: aws.String("a,b")},
+	}, &unsupported)
+	assert.ErrorContains(t, err, "unsupported field type []string")
+}
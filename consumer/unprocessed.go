@@ -0,0 +1,66 @@
+package consumer
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"sync"
+)
+
+// unprocessed collects the messages left undeleted by the consumer while it stops, for OnShutdownUnprocessed.
+type unprocessed struct {
+	mu       sync.Mutex
+	messages []types.Message
+}
+
+// trackUnprocessed returns the received messages to report should the consumer stop during their poll cycle,
+// nil when OnShutdownUnprocessed is unset or the messages were deleted on receipt.
+func (s *SQS) trackUnprocessed(w *worker, msgs []types.Message) []types.Message {
+	if s.config.OnShutdownUnprocessed == nil || s.strategy(w) == DeleteStrategyImmediate {
+		return nil
+	}
+
+	tracked := make([]types.Message, len(msgs))
+	for i, msg := range msgs {
+		tracked[i] = s.releaseMessage(msg)
+	}
+	return tracked
+}
+
+// addUnprocessed records the tracked messages of a poll cycle interrupted by the consumer stopping that were not
+// acknowledged.
+func (s *SQS) addUnprocessed(tracked, acked []types.Message) {
+	if s.config.OnShutdownUnprocessed == nil || len(tracked) == 0 {
+		return
+	}
+
+	ids := make(map[string]bool, len(acked))
+	for _, msg := range acked {
+		ids[aws.ToString(msg.MessageId)] = true
+	}
+
+	s.unprocessed.mu.Lock()
+	defer s.unprocessed.mu.Unlock()
+	for _, msg := range tracked {
+		if !ids[aws.ToString(msg.MessageId)] {
+			s.unprocessed.messages = append(s.unprocessed.messages, msg)
+		}
+	}
+}
+
+// reportUnprocessed calls OnShutdownUnprocessed with the messages collected while stopping, if any.
+func (s *SQS) reportUnprocessed() {
+	s.unprocessed.mu.Lock()
+	msgs := s.unprocessed.messages
+	s.unprocessed.messages = nil
+	s.unprocessed.mu.Unlock()
+
+	if s.config.OnShutdownUnprocessed == nil || len(msgs) == 0 {
+		return
+	}
+
+	report := make([]Message, len(msgs))
+	for i, msg := range msgs {
+		report[i] = s.message(msg)
+	}
+	s.config.OnShutdownUnprocessed(report)
+}
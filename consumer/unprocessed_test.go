@@ -0,0 +1,56 @@
+package consumer
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSQS_OnShutdownUnprocessed(t *testing.T) {
+	sqsMock := new(SqsMock)
+	sqsMock.On("ReceiveMessage", mock.Anything, mock.AnythingOfType("*sqs.ReceiveMessageInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+	sqsMock.On("DeleteMessageBatch", mock.Anything, mock.AnythingOfType("*sqs.DeleteMessageBatchInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+
+	var unprocessed []string
+	s := &SQS{sqs: sqsMock, config: &SQSConf{
+		Queue:                 "queue",
+		Concurrency:           1,
+		DeleteStrategy:        DeleteStrategyOnSuccess,
+		DisableSignalHandling: true,
+		OnShutdownUnprocessed: func(msgs []Message) {
+			for _, msg := range msgs {
+				unprocessed = append(unprocessed, aws.ToString(msg.MessageId))
+			}
+		},
+	}}
+
+	consuming := make(chan struct{}, 1)
+	done := make(chan error)
+	go func() {
+		done <- s.StartWithContext(context.Background(), func(ctx context.Context, data []byte, _ map[string]types.MessageAttributeValue) error {
+			if string(data) == "msg1" {
+				return nil
+			}
+			select {
+			case consuming <- struct{}{}:
+			default:
+			}
+			<-ctx.Done()
+			return ctx.Err()
+		})
+	}()
+
+	<-consuming
+	s.Stop()
+	require.NoError(t, <-done)
+
+	assert.Equal(t, []string{"msg2", "msg3"}, unprocessed)
+	require.Len(t, sqsMock.deleteInputs, 1)
+	assert.Equal(t, "msg1", aws.ToString(sqsMock.deleteInputs[0].Entries[0].Id))
+}
@@ -0,0 +1,181 @@
+package consumer
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	maxWaitTimeSeconds   = 20
+	maxVisibilitySeconds = int32(maxVisibilityTimeout / time.Second)
+)
+
+// Validate checks the configuration and returns every problem found, joined. Zero values standing for a default
+// are valid. Unset required fields wrap their own sentinel error, the other problems SentinelErrorInvalidConfig.
+// The problems are always reported in the same order.
+func (c *SQSConf) Validate() error {
+	if c == nil {
+		return SentinelErrorConfigIsNil
+	}
+
+	var errs []error
+	invalid := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%w: %s", SentinelErrorInvalidConfig, fmt.Sprintf(format, args...)))
+	}
+
+	if c.Queue == "" && c.QueueName == "" {
+		errs = append(errs, SentinelErrorQueueNotSet)
+	}
+
+	if c.ExtendedClient && c.S3Client == nil {
+		errs = append(errs, SentinelErrorS3ClientNotSet)
+	}
+
+	if c.OldestMessageAgeInterval > 0 && c.OldestMessageAge == nil {
+		errs = append(errs, SentinelErrorOldestMessageAgeNotSet)
+	}
+
+	if c.FIFO && c.Queue != "" && !strings.HasSuffix(c.Queue, ".fifo") {
+		invalid("FIFO is set but queue %q is not a FIFO queue", c.Queue)
+	}
+
+	if c.EndpointURL != "" {
+		if u, err := url.Parse(c.EndpointURL); err != nil || u.Scheme == "" || u.Host == "" {
+			invalid("EndpointURL %q is not an absolute URL", c.EndpointURL)
+		}
+	}
+
+	if c.Concurrency < 0 {
+		invalid("Concurrency must not be negative, got %d", c.Concurrency)
+	}
+
+	if c.MaxNumberOfMessages < 0 || c.MaxNumberOfMessages > maxBatchSize {
+		invalid("MaxNumberOfMessages must be between 1 and %d, got %d", maxBatchSize, c.MaxNumberOfMessages)
+	}
+
+	if c.VisibilityTimeout < 0 || c.VisibilityTimeout > maxVisibilitySeconds {
+		invalid("VisibilityTimeout must be between 0 and %d seconds, got %d", maxVisibilitySeconds, c.VisibilityTimeout)
+	}
+
+	if c.WaitTimeSeconds < 0 || c.WaitTimeSeconds > maxWaitTimeSeconds {
+		invalid("WaitTimeSeconds must be between 1 and %d, got %d", maxWaitTimeSeconds, c.WaitTimeSeconds)
+	}
+
+	switch c.DeleteStrategy {
+	case "", DeleteStrategyImmediate, DeleteStrategyOnSuccess, DeleteStrategyBatched, DeleteStrategyNever:
+	default:
+		invalid("unknown DeleteStrategy %q", c.DeleteStrategy)
+	}
+
+	switch c.PanicPolicy {
+	case "", PanicPolicyStop, PanicPolicyCircuit, PanicPolicyContinue:
+	default:
+		invalid("unknown PanicPolicy %q", c.PanicPolicy)
+	}
+
+	for _, f := range []struct {
+		name   string
+		action DecodeErrorAction
+	}{
+		{"DecodeErrorAction", c.DecodeErrorAction},
+		{"OversizedBodyAction", c.OversizedBodyAction},
+		{"StaleMessageAction", c.StaleMessageAction},
+		{"BodyCharsetErrorAction", c.BodyCharsetErrorAction},
+		{"DecompressErrorAction", c.DecompressErrorAction},
+	} {
+		switch f.action {
+		case "", DecodeErrorDelete, DecodeErrorKeep:
+		case DecodeErrorDeadLetter:
+			if c.DeadLetterQueueURL == "" && !c.DiscoverDeadLetterQueue {
+				errs = append(errs, fmt.Errorf("%w for %s", SentinelErrorDeadLetterQueueNotSet, f.name))
+			}
+		default:
+			invalid("unknown %s %q", f.name, f.action)
+		}
+	}
+
+	if c.InitialVisibilityExtension < 0 || c.InitialVisibilityExtension > maxVisibilityTimeout {
+		invalid("InitialVisibilityExtension must be between 0 and %s, got %s", maxVisibilityTimeout, c.InitialVisibilityExtension)
+	}
+
+	if c.VisibilityHeartbeat >= c.visibilityTimeout() {
+		invalid("VisibilityHeartbeat %s must be shorter than the visibility timeout %s", c.VisibilityHeartbeat, c.visibilityTimeout())
+	}
+
+	if c.ThrottleBackoff > 0 && c.MaxThrottleBackoff > 0 && c.ThrottleBackoff > c.MaxThrottleBackoff {
+		invalid("ThrottleBackoff %s exceeds MaxThrottleBackoff %s", c.ThrottleBackoff, c.MaxThrottleBackoff)
+	}
+
+	if c.IdempotencyKeyFunc != nil && c.DedupWindow == 0 && c.IdempotencyStore == nil {
+		invalid("IdempotencyKeyFunc is set but deduplication is disabled, set DedupWindow or IdempotencyStore")
+	}
+
+	if c.RetryBudgetRate < 0 {
+		invalid("RetryBudgetRate must not be negative, got %g", c.RetryBudgetRate)
+	}
+	if c.GroupRateLimit < 0 {
+		invalid("GroupRateLimit must not be negative, got %g", c.GroupRateLimit)
+	}
+
+	if c.TotalShards > 1 && (c.ShardIndex < 0 || c.ShardIndex >= c.TotalShards) {
+		invalid("ShardIndex must be within [0, %d), got %d", c.TotalShards, c.ShardIndex)
+	}
+
+	for _, f := range []struct {
+		name string
+		n    int
+	}{
+		{"StartupProbeAttempts", c.StartupProbeAttempts},
+		{"QueueDoesNotExistThreshold", c.QueueDoesNotExistThreshold},
+		{"CircuitBreakerThreshold", c.CircuitBreakerThreshold},
+		{"MaxInFlight", c.MaxInFlight},
+		{"MaxInFlightPerGroup", c.MaxInFlightPerGroup},
+		{"GroupRateBurst", c.GroupRateBurst},
+		{"MaxBodyBytes", c.MaxBodyBytes},
+		{"LogSampleEvery", c.LogSampleEvery},
+		{"LogSamplePerSecond", c.LogSamplePerSecond},
+		{"PanicThreshold", c.PanicThreshold},
+		{"TotalShards", c.TotalShards},
+		{"DeleteRetryBufferSize", c.DeleteRetryBufferSize},
+		{"RetryBudgetBurst", c.RetryBudgetBurst},
+		{"WindowSize", c.WindowSize},
+	} {
+		if f.n < 0 {
+			invalid("%s must not be negative, got %d", f.name, f.n)
+		}
+	}
+
+	for _, f := range []struct {
+		name string
+		d    time.Duration
+	}{
+		{"StartupProbeBackoff", c.StartupProbeBackoff},
+		{"ThrottleBackoff", c.ThrottleBackoff},
+		{"MaxThrottleBackoff", c.MaxThrottleBackoff},
+		{"TransientErrorDelay", c.TransientErrorDelay},
+		{"EmptyReceiveJitter", c.EmptyReceiveJitter},
+		{"DedupWindow", c.DedupWindow},
+		{"CircuitBreakerCooldown", c.CircuitBreakerCooldown},
+		{"SlowHandlerThreshold", c.SlowHandlerThreshold},
+		{"PanicWindow", c.PanicWindow},
+		{"VisibilityHeartbeat", c.VisibilityHeartbeat},
+		{"VisibilityDeadlineMargin", c.VisibilityDeadlineMargin},
+		{"MaxHandlerRetryDuration", c.MaxHandlerRetryDuration},
+		{"UnderDeliveryWindow", c.UnderDeliveryWindow},
+		{"MinPollInterval", c.MinPollInterval},
+		{"OldestMessageAgeInterval", c.OldestMessageAgeInterval},
+		{"QueueDepthInterval", c.QueueDepthInterval},
+		{"ClockSkewTolerance", c.ClockSkewTolerance},
+		{"WindowDuration", c.WindowDuration},
+		{"DrainTimeout", c.DrainTimeout},
+	} {
+		if f.d < 0 {
+			invalid("%s must not be negative, got %s", f.name, f.d)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,55 @@
+package consumer
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestSQSConf_Validate(t *testing.T) {
+	assert.ErrorIs(t, (*SQSConf)(nil).Validate(), SentinelErrorConfigIsNil)
+	assert.NoError(t, (&SQSConf{Queue: "queue"}).Validate())
+	assert.NoError(t, (&SQSConf{QueueName: "queue.fifo", FIFO: true, MaxNumberOfMessages: 10, WaitTimeSeconds: 20}).Validate())
+
+	err := (&SQSConf{
+		ExtendedClient:      true,
+		FIFO:                true,
+		Queue:               "https://sqs.eu-west-1.amazonaws.com/123456789012/queue",
+		MaxNumberOfMessages: 11,
+		WaitTimeSeconds:     21,
+		DeleteStrategy:      "NEVER_HEARD_OF",
+		DecodeErrorAction:   DecodeErrorDeadLetter,
+		ThrottleBackoff:     time.Minute,
+		MaxThrottleBackoff:  time.Second,
+		PanicWindow:         -time.Second,
+		TotalShards:         3,
+		ShardIndex:          3,
+		EndpointURL:         "localhost:4566",
+
+		OldestMessageAgeInterval: time.Minute,
+	}).Validate()
+
+	assert.ErrorIs(t, err, SentinelErrorS3ClientNotSet)
+	assert.ErrorIs(t, err, SentinelErrorDeadLetterQueueNotSet)
+	assert.ErrorIs(t, err, SentinelErrorOldestMessageAgeNotSet)
+	assert.ErrorIs(t, err, SentinelErrorInvalidConfig)
+	assert.NotErrorIs(t, err, SentinelErrorQueueNotSet)
+	for _, want := range []string{
+		"is not a FIFO queue",
+		"MaxNumberOfMessages must be between 1 and 10, got 11",
+		"WaitTimeSeconds must be between 1 and 20, got 21",
+		`unknown DeleteStrategy "NEVER_HEARD_OF"`,
+		"ThrottleBackoff 1m0s exceeds MaxThrottleBackoff 1s",
+		"PanicWindow must not be negative",
+		"ShardIndex must be within [0, 3), got 3",
+		`EndpointURL "localhost:4566" is not an absolute URL`,
+	} {
+		assert.Contains(t, err.Error(), want)
+	}
+
+	negative := &SQSConf{Queue: "queue", MaxInFlight: -1, PanicThreshold: -1, DedupWindow: -time.Second, PanicWindow: -time.Second}
+	assert.Equal(t, "invalid configuration: MaxInFlight must not be negative, got -1\n"+
+		"invalid configuration: PanicThreshold must not be negative, got -1\n"+
+		"invalid configuration: DedupWindow must not be negative, got -1s\n"+
+		"invalid configuration: PanicWindow must not be negative, got -1s", negative.Validate().Error())
+}
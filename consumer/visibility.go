@@ -0,0 +1,43 @@
+package consumer
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"log/slog"
+	"time"
+)
+
+// extendVisibility sets the visibility timeout of the received messages to d, ten messages per request.
+// Failures are logged: the messages keep the visibility timeout they were received with.
+func (s *SQS) extendVisibility(ctx context.Context, msgs []types.Message, d time.Duration) {
+	timeout := int32((min(d, maxVisibilityTimeout) + time.Second - 1) / time.Second)
+
+	for _, c := range chunk(msgs, maxBatchSize) {
+		entries := make([]types.ChangeMessageVisibilityBatchRequestEntry, len(c))
+		for i, msg := range c {
+			entries[i] = types.ChangeMessageVisibilityBatchRequestEntry{
+				Id:                msg.MessageId,
+				ReceiptHandle:     msg.ReceiptHandle,
+				VisibilityTimeout: timeout,
+			}
+		}
+
+		out, err := s.sqs.ChangeMessageVisibilityBatch(ctx, &sqs.ChangeMessageVisibilityBatchInput{
+			Entries:  entries,
+			QueueUrl: aws.String(s.config.Queue),
+		})
+		if err != nil {
+			s.logger().Error("error extending visibility timeout", slog.Any("error", err.Error()), requestIDAttr(err))
+			continue
+		}
+
+		for _, failed := range out.Failed {
+			s.logger().Error("error extending visibility timeout",
+				slog.String("messageId", aws.ToString(failed.Id)),
+				slog.String("code", aws.ToString(failed.Code)),
+				slog.String("error", aws.ToString(failed.Message)))
+		}
+	}
+}
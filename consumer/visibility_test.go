@@ -0,0 +1,76 @@
+package consumer
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestSQS_InitialVisibilityExtension(t *testing.T) {
+	sqsMock := new(SqsMock)
+	sqsMock.On("ReceiveMessage", mock.Anything, mock.AnythingOfType("*sqs.ReceiveMessageInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+	sqsMock.On("ChangeMessageVisibilityBatch", mock.Anything, mock.AnythingOfType("*sqs.ChangeMessageVisibilityBatchInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+	sqsMock.On("DeleteMessageBatch", mock.Anything, mock.AnythingOfType("*sqs.DeleteMessageBatchInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+
+	s := &SQS{sqs: sqsMock, config: &SQSConf{
+		Queue:                      "queue",
+		DeleteStrategy:             DeleteStrategyOnSuccess,
+		InitialVisibilityExtension: 90 * time.Second,
+	}}
+
+	extended := 0
+	consumeFn := func(_ context.Context, data []byte, attributes map[string]types.MessageAttributeValue) error {
+		extended = len(sqsMock.visibilityBatchInputs)
+		return nil
+	}
+
+	processed, err := s.pollCycle(context.Background(), s.consumeEach(consumeFn), &worker{})
+	require.NoError(t, err)
+	assert.Equal(t, 3, processed)
+
+	assert.Equal(t, 1, extended)
+	require.Len(t, sqsMock.visibilityBatchInputs[0].Entries, 3)
+	for _, entry := range sqsMock.visibilityBatchInputs[0].Entries {
+		assert.Equal(t, int32(90), entry.VisibilityTimeout)
+	}
+}
+
+func TestSQS_Peek(t *testing.T) {
+	sqsMock := new(SqsMock)
+	sqsMock.On("ReceiveMessage", mock.Anything, mock.AnythingOfType("*sqs.ReceiveMessageInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+	sqsMock.On("ChangeMessageVisibilityBatch", mock.Anything, mock.AnythingOfType("*sqs.ChangeMessageVisibilityBatchInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+
+	s := &SQS{sqs: sqsMock, config: &SQSConf{Queue: "queue"}}
+
+	seen := make([]string, 0)
+	require.NoError(t, s.Peek(context.Background(), func(msg Message) bool {
+		seen = append(seen, *msg.MessageId)
+		return true
+	}))
+
+	assert.Equal(t, []string{"msg1", "msg2", "msg3"}, seen)
+	assert.Len(t, sqsMock.inputs, 2)
+	assert.Empty(t, sqsMock.deleteInputs)
+	require.Len(t, sqsMock.visibilityBatchInputs, 1)
+	assert.Len(t, sqsMock.visibilityBatchInputs[0].Entries, 3)
+	for _, entry := range sqsMock.visibilityBatchInputs[0].Entries {
+		assert.Equal(t, int32(0), entry.VisibilityTimeout)
+	}
+
+	seen = seen[:0]
+	require.NoError(t, s.Peek(context.Background(), func(msg Message) bool {
+		seen = append(seen, *msg.MessageId)
+		return false
+	}))
+	assert.Equal(t, []string{"msg1"}, seen)
+	assert.Len(t, sqsMock.visibilityBatchInputs, 2)
+}
@@ -0,0 +1,200 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// windowTicks is the number of times per WindowDuration, or per heartbeat when shorter, the window is checked.
+const windowTicks = 10
+
+// WindowHandler consumes the messages of a window, see StartWindowed. They are deleted when it returns nil.
+type WindowHandler func(msgs []Message) error
+
+// window buffers the received messages of StartWindowed until they are flushed.
+type window struct {
+	mu       sync.Mutex
+	messages []types.Message
+	// opened is when the first message of the window was buffered
+	opened time.Time
+	// extended is when the visibility timeout of the buffered messages was last extended
+	extended time.Time
+
+	// flushing serializes the WindowHandler calls, w holding their panic window
+	flushing sync.Mutex
+	w        worker
+}
+
+func (c *SQSConf) windowSize() int {
+	if c.WindowSize > 0 {
+		return c.WindowSize
+	}
+	return DefaultWindowSize
+}
+
+func (c *SQSConf) windowDuration() time.Duration {
+	if c.WindowDuration > 0 {
+		return c.WindowDuration
+	}
+	return DefaultWindowDuration
+}
+
+// windowHeartbeat is how often the visibility timeout of the buffered messages is extended: VisibilityHeartbeat,
+// or half the visibility timeout.
+func (c *SQSConf) windowHeartbeat() time.Duration {
+	if c.VisibilityHeartbeat > 0 {
+		return c.VisibilityHeartbeat
+	}
+	return c.visibilityTimeout() / 2
+}
+
+// StartWindowed consumes the messages in windows for bulk writes: the received messages are buffered until
+// WindowSize of them are, or WindowDuration elapsed since the first one was, then handed to consumeFn at once.
+// The messages of a window are deleted when consumeFn returns nil and redelivered otherwise. Their visibility
+// timeout is extended while they are buffered, every VisibilityHeartbeat or half the visibility timeout. The
+// window being consumed when the consumer stops is flushed before Start returns. It requires a DeleteStrategy
+// deleting messages on success, DeleteStrategyImmediate deleting them before they are consumed.
+func (s *SQS) StartWindowed(ctx context.Context, consumeFn WindowHandler) error {
+	if strategy := s.DeleteStrategy(); strategy != DeleteStrategyOnSuccess && strategy != DeleteStrategyBatched {
+		return fmt.Errorf("%w: StartWindowed requires DeleteStrategyOnSuccess or DeleteStrategyBatched", SentinelErrorInvalidConfig)
+	}
+	return s.start(ctx, s.consumeWindowed(consumeFn))
+}
+
+func (s *SQS) consumeWindowed(consumeFn WindowHandler) batchHandler {
+	return batchHandler{
+		consume: func(ctx context.Context, _ *worker, msgs []types.Message) []types.Message {
+			if s.bufferWindow(msgs) {
+				s.flushWindow(ctx, consumeFn)
+			}
+			// the window deletes its messages once flushed
+			return nil
+		},
+		background: func(ctx context.Context) {
+			s.runWindow(ctx, consumeFn)
+		},
+	}
+}
+
+// bufferWindow adds msgs to the window and reports whether it is full.
+func (s *SQS) bufferWindow(msgs []types.Message) bool {
+	win := &s.window
+	win.mu.Lock()
+	defer win.mu.Unlock()
+
+	if len(win.messages) == 0 {
+		win.opened = s.now()
+		win.extended = win.opened
+	}
+	win.messages = append(win.messages, msgs...)
+	return len(win.messages) >= s.config.windowSize()
+}
+
+// runWindow flushes the window once WindowDuration elapsed and extends the visibility timeout of its messages
+// until ctx is done, flushing it one last time.
+func (s *SQS) runWindow(ctx context.Context, consumeFn WindowHandler) {
+	heartbeat := s.config.windowHeartbeat()
+	tick := max(min(s.config.windowDuration(), heartbeat)/windowTicks, time.Millisecond)
+
+	for {
+		s.sleep(ctx, tick)
+		if ctx.Err() != nil {
+			s.flushWindow(context.WithoutCancel(ctx), consumeFn)
+			return
+		}
+
+		due, extend := s.checkWindow(heartbeat)
+		switch {
+		case due:
+			s.flushWindow(ctx, consumeFn)
+		case len(extend) > 0:
+			s.extendVisibility(ctx, extend, s.config.visibilityTimeout())
+		}
+	}
+}
+
+// checkWindow reports whether the window is due, or else returns its messages when their visibility timeout
+// must be extended.
+func (s *SQS) checkWindow(heartbeat time.Duration) (bool, []types.Message) {
+	win := &s.window
+	win.mu.Lock()
+	defer win.mu.Unlock()
+
+	if len(win.messages) == 0 {
+		return false, nil
+	}
+
+	now := s.now()
+	if now.Sub(win.opened) >= s.config.windowDuration() {
+		return true, nil
+	}
+	if now.Sub(win.extended) < heartbeat {
+		return false, nil
+	}
+
+	win.extended = now
+	extend := make([]types.Message, len(win.messages))
+	for i, msg := range win.messages {
+		extend[i] = types.Message{MessageId: msg.MessageId, ReceiptHandle: msg.ReceiptHandle}
+	}
+	return false, extend
+}
+
+func (s *SQS) takeWindow() []types.Message {
+	win := &s.window
+	win.mu.Lock()
+	defer win.mu.Unlock()
+
+	msgs := win.messages
+	win.messages = nil
+	return msgs
+}
+
+// flushWindow hands the buffered messages to consumeFn and deletes them when it succeeds. The messages of a
+// failed window are left for redelivery.
+func (s *SQS) flushWindow(ctx context.Context, consumeFn WindowHandler) {
+	win := &s.window
+	win.flushing.Lock()
+	defer win.flushing.Unlock()
+
+	msgs := s.takeWindow()
+	if len(msgs) == 0 {
+		return
+	}
+
+	batch := make([]Message, len(msgs))
+	for i, msg := range msgs {
+		batch[i] = s.message(msg)
+	}
+
+	started := s.now()
+	err := s.protect(&win.w, func() error {
+		return consumeFn(batch)
+	})
+	elapsed := s.since(started)
+	s.checkSlow(elapsed, slog.Int("messages", len(batch)))
+	s.observeConsumption(elapsed, len(batch))
+
+	outcome := AuditOutcomeSuccess
+	if err != nil {
+		outcome = AuditOutcomeFailure
+	}
+	for _, msg := range msgs {
+		s.audit(msg, elapsed, outcome, err)
+	}
+
+	if err != nil {
+		s.failed(len(msgs), err)
+		return
+	}
+	s.succeeded(len(msgs))
+
+	if err := s.ackMessages(context.WithoutCancel(ctx), &win.w, msgs); err != nil {
+		s.logger().Error("error deleting window messages, they will be redelivered",
+			slog.Int("messages", len(msgs)), slog.Any("error", err.Error()))
+	}
+}
@@ -0,0 +1,124 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func TestSQS_consumeWindowed(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		wantDeleted   int
+		wantProcessed int64
+		wantFailed    int64
+	}{
+		{name: "shouldDeleteWindowOnSuccess", wantDeleted: 6, wantProcessed: 6},
+		{name: "shouldLeaveFailedWindow", err: errors.New("bulk write failed"), wantFailed: 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqsMock := new(SqsMock)
+			sqsMock.On("DeleteMessageBatch", mock.Anything, mock.AnythingOfType("*sqs.DeleteMessageBatchInput"),
+				mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+
+			s := &SQS{sqs: sqsMock, clock: &fakeClock{now: time.Unix(1700000000, 0)},
+				config: &SQSConf{Queue: "queue", DeleteStrategy: DeleteStrategyOnSuccess, WindowSize: 5}}
+
+			var windows [][]string
+			handler := s.consumeWindowed(func(msgs []Message) error {
+				ids := make([]string, len(msgs))
+				for i, msg := range msgs {
+					ids[i] = aws.ToString(msg.MessageId)
+				}
+				windows = append(windows, ids)
+				return tt.err
+			})
+
+			toDelete, _ := s.consumeMessages(context.Background(), &worker{}, getQueueContent().Messages, handler)
+			assert.Empty(t, toDelete)
+			assert.Empty(t, windows, "the window is not full yet")
+
+			toDelete, _ = s.consumeMessages(context.Background(), &worker{}, getQueueContent().Messages, handler)
+			assert.Empty(t, toDelete)
+			assert.Equal(t, [][]string{{"msg1", "msg2", "msg3", "msg1", "msg2", "msg3"}}, windows)
+
+			deleted := 0
+			for _, input := range sqsMock.deleteInputs {
+				deleted += len(input.Entries)
+			}
+			assert.Equal(t, tt.wantDeleted, deleted)
+			assert.Empty(t, s.takeWindow())
+
+			st := s.Stats()
+			assert.Equal(t, tt.wantProcessed, st.ProcessedTotal)
+			assert.Equal(t, tt.wantFailed, st.FailedTotal)
+		})
+	}
+}
+
+func TestSQS_checkWindow(t *testing.T) {
+	clk := &fakeClock{now: time.Unix(1700000000, 0)}
+	s := &SQS{clock: clk, config: &SQSConf{Queue: "queue", WindowDuration: time.Minute}}
+
+	due, extend := s.checkWindow(20 * time.Second)
+	assert.False(t, due)
+	assert.Empty(t, extend, "an empty window is never due")
+
+	s.bufferWindow(getQueueContent().Messages)
+
+	clk.After(10 * time.Second)
+	due, extend = s.checkWindow(20 * time.Second)
+	assert.False(t, due)
+	assert.Empty(t, extend)
+
+	clk.After(15 * time.Second)
+	due, extend = s.checkWindow(20 * time.Second)
+	assert.False(t, due)
+	assert.Len(t, extend, 3)
+	assert.Equal(t, "handle1", aws.ToString(extend[0].ReceiptHandle))
+	assert.Nil(t, extend[0].Body)
+
+	clk.After(10 * time.Second)
+	_, extend = s.checkWindow(20 * time.Second)
+	assert.Empty(t, extend, "extended less than a heartbeat ago")
+
+	clk.After(30 * time.Second)
+	due, _ = s.checkWindow(20 * time.Second)
+	assert.True(t, due)
+}
+
+func TestSQS_runWindowFlushesOnStop(t *testing.T) {
+	sqsMock := new(SqsMock)
+	sqsMock.On("DeleteMessageBatch", mock.Anything, mock.AnythingOfType("*sqs.DeleteMessageBatchInput"),
+		mock.AnythingOfType("[]func(*sqs.Options)")).Return(nil, nil)
+
+	s := &SQS{sqs: sqsMock, clock: &fakeClock{now: time.Unix(1700000000, 0)},
+		config: &SQSConf{Queue: "queue", DeleteStrategy: DeleteStrategyOnSuccess}}
+	s.bufferWindow(getQueueContent().Messages)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	flushed := 0
+	s.runWindow(ctx, func(msgs []Message) error {
+		flushed += len(msgs)
+		return nil
+	})
+
+	assert.Equal(t, 3, flushed)
+	assert.Len(t, sqsMock.deleteInputs, 1)
+}
+
+func TestSQS_StartWindowedRequiresDeleteOnSuccess(t *testing.T) {
+	s := &SQS{config: &SQSConf{Queue: "queue", DeleteStrategy: DeleteStrategyImmediate}}
+
+	err := s.StartWindowed(context.Background(), func([]Message) error { return nil })
+	assert.True(t, errors.Is(err, SentinelErrorInvalidConfig))
+}
@@ -0,0 +1,145 @@
+// Package emf records the consumer metrics in the CloudWatch Embedded Metric Format: every measurement is written
+// as a structured log line that CloudWatch extracts metrics from, without any agent on Lambda or ECS.
+package emf
+
+import (
+	"encoding/json"
+	"github.com/ducksify/sqs-consume/consumer"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultNamespace = "SQSConsume"
+
+	queueDimension = "Queue"
+)
+
+type Config struct {
+	// Namespace defaults to DefaultNamespace.
+	Namespace string
+	// Dimensions are added to every metric next to the Queue dimension.
+	Dimensions map[string]string
+	// Writer defaults to os.Stdout.
+	Writer io.Writer
+}
+
+// Recorder is a consumer.MetricsRecorder writing EMF log lines.
+type Recorder struct {
+	mu         sync.Mutex
+	namespace  string
+	dimensions map[string]string
+	keys       []string
+	w          io.Writer
+	now        func() time.Time
+}
+
+var (
+	_ consumer.MetricsRecorder          = (*Recorder)(nil)
+	_ consumer.OldestMessageAgeRecorder = (*Recorder)(nil)
+	_ consumer.BackpressureRecorder     = (*Recorder)(nil)
+	_ consumer.QueueDepthRecorder       = (*Recorder)(nil)
+)
+
+type metric struct {
+	name  string
+	unit  string
+	value float64
+}
+
+func NewRecorder(conf Config) *Recorder {
+	if conf.Namespace == "" {
+		conf.Namespace = DefaultNamespace
+	}
+	if conf.Writer == nil {
+		conf.Writer = os.Stdout
+	}
+
+	keys := []string{queueDimension}
+	for k := range conf.Dimensions {
+		if k != queueDimension {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys[1:])
+
+	return &Recorder{
+		namespace:  conf.Namespace,
+		dimensions: conf.Dimensions,
+		keys:       keys,
+		w:          conf.Writer,
+		now:        time.Now,
+	}
+}
+
+func (r *Recorder) MessagesReceived(queue string, n int) {
+	r.emit(queue, metric{name: "MessagesReceived", unit: "Count", value: float64(n)})
+}
+
+func (r *Recorder) QueueLatency(queue string, latency time.Duration) {
+	r.emit(queue, metric{name: "QueueLatency", unit: "Milliseconds", value: float64(latency) / float64(time.Millisecond)})
+}
+
+func (r *Recorder) MessagesProcessed(queue string, succeeded, failed int, elapsed time.Duration) {
+	r.emit(queue,
+		metric{name: "MessagesProcessed", unit: "Count", value: float64(succeeded)},
+		metric{name: "MessagesFailed", unit: "Count", value: float64(failed)},
+		metric{name: "ProcessingLatency", unit: "Milliseconds", value: float64(elapsed) / float64(time.Millisecond)},
+	)
+}
+
+func (r *Recorder) MessagesDeleted(queue string, n int) {
+	r.emit(queue, metric{name: "MessagesDeleted", unit: "Count", value: float64(n)})
+}
+
+func (r *Recorder) OldestMessageAge(queue string, age time.Duration) {
+	r.emit(queue, metric{name: "OldestMessageAge", unit: "Seconds", value: age.Seconds()})
+}
+
+func (r *Recorder) QueueDepth(queue string, depth int) {
+	r.emit(queue, metric{name: "QueueDepth", unit: "Count", value: float64(depth)})
+}
+
+func (r *Recorder) WorkQueueDepth(queue string, depth int) {
+	r.emit(queue, metric{name: "WorkQueueDepth", unit: "Count", value: float64(depth)})
+}
+
+func (r *Recorder) PollerBlocked(queue string, waited time.Duration) {
+	r.emit(queue, metric{name: "PollerBlockedTime", unit: "Milliseconds", value: float64(waited) / float64(time.Millisecond)})
+}
+
+func (r *Recorder) emit(queue string, metrics ...metric) {
+	definitions := make([]map[string]string, len(metrics))
+	doc := make(map[string]any, len(r.keys)+len(metrics)+1)
+
+	for k, v := range r.dimensions {
+		doc[k] = v
+	}
+	doc[queueDimension] = queue
+
+	for i, m := range metrics {
+		definitions[i] = map[string]string{"Name": m.name, "Unit": m.unit}
+		doc[m.name] = m.value
+	}
+
+	doc["_aws"] = map[string]any{
+		"Timestamp": r.now().UnixMilli(),
+		"CloudWatchMetrics": []map[string]any{{
+			"Namespace":  r.namespace,
+			"Dimensions": [][]string{r.keys},
+			"Metrics":    definitions,
+		}},
+	}
+
+	line, err := json.Marshal(doc)
+	if err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, _ = r.w.Write(append(line, '\n'))
+}
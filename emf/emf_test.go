@@ -0,0 +1,81 @@
+package emf
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecorder(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewRecorder(Config{Namespace: "test", Dimensions: map[string]string{"Service": "billing"}, Writer: &buf})
+	r.now = func() time.Time { return time.UnixMilli(1700000000000) }
+
+	r.MessagesReceived("orders", 3)
+	r.MessagesProcessed("orders", 2, 1, 1500*time.Microsecond)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var received map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &received))
+	assert.JSONEq(t, `{
+		"_aws": {
+			"Timestamp": 1700000000000,
+			"CloudWatchMetrics": [{
+				"Namespace": "test",
+				"Dimensions": [["Queue", "Service"]],
+				"Metrics": [{"Name": "MessagesReceived", "Unit": "Count"}]
+			}]
+		},
+		"Queue": "orders",
+		"Service": "billing",
+		"MessagesReceived": 3
+	}`, lines[0])
+
+	var processed map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &processed))
+	assert.Equal(t, 2.0, processed["MessagesProcessed"])
+	assert.Equal(t, 1.0, processed["MessagesFailed"])
+	assert.Equal(t, 1.5, processed["ProcessingLatency"])
+}
+
+func TestRecorder_OldestMessageAge(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewRecorder(Config{Writer: &buf})
+
+	r.OldestMessageAge("orders", 90*time.Second)
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, 90.0, line["OldestMessageAge"])
+	assert.Equal(t, "orders", line["Queue"])
+}
+
+func TestRecorder_Backpressure(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewRecorder(Config{Writer: &buf})
+
+	r.WorkQueueDepth("orders", 7)
+	r.PollerBlocked("orders", 250*time.Millisecond)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var depth, blocked map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &depth))
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &blocked))
+	assert.Equal(t, 7.0, depth["WorkQueueDepth"])
+	assert.Equal(t, 250.0, blocked["PollerBlockedTime"])
+}
+
+func TestNewRecorderDefaults(t *testing.T) {
+	r := NewRecorder(Config{})
+	assert.Equal(t, DefaultNamespace, r.namespace)
+	assert.Equal(t, []string{"Queue"}, r.keys)
+	assert.NotNil(t, r.w)
+}
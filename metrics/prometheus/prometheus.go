@@ -0,0 +1,134 @@
+// Package prometheus records the consumer metrics as Prometheus collectors with standard names, labelled with the
+// queue and the consumer name.
+package prometheus
+
+import (
+	"github.com/ducksify/sqs-consume/consumer"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"time"
+)
+
+const (
+	DefaultNamespace = "sqs_consume"
+
+	queueLabel    = "queue"
+	consumerLabel = "consumer"
+)
+
+type Config struct {
+	// Namespace prefixes the metric names, defaults to DefaultNamespace.
+	Namespace string
+	// Consumer is the consumer label of every metric, typically the SQSConf.Name of the consumer.
+	Consumer string
+	// Registerer defaults to prometheus.DefaultRegisterer.
+	Registerer prom.Registerer
+	// Buckets of the process duration and queue latency histograms, in seconds, default to prometheus.DefBuckets.
+	Buckets []float64
+}
+
+// Recorder is a consumer.MetricsRecorder updating Prometheus collectors.
+type Recorder struct {
+	received         *prom.CounterVec
+	processed        *prom.CounterVec
+	failed           *prom.CounterVec
+	deleted          *prom.CounterVec
+	processDuration  *prom.HistogramVec
+	queueLatency     *prom.HistogramVec
+	queueDepth       *prom.GaugeVec
+	oldestMessageAge *prom.GaugeVec
+	workQueueDepth   *prom.GaugeVec
+	pollerBlocked    *prom.CounterVec
+}
+
+var (
+	_ consumer.MetricsRecorder          = (*Recorder)(nil)
+	_ consumer.OldestMessageAgeRecorder = (*Recorder)(nil)
+	_ consumer.BackpressureRecorder     = (*Recorder)(nil)
+	_ consumer.QueueDepthRecorder       = (*Recorder)(nil)
+)
+
+// NewRecorder creates the collectors and registers them on Config.Registerer. The collectors of recorders
+// created for several consumers sharing a registerer must have different Consumer labels.
+func NewRecorder(conf Config) (*Recorder, error) {
+	if conf.Namespace == "" {
+		conf.Namespace = DefaultNamespace
+	}
+	if conf.Registerer == nil {
+		conf.Registerer = prom.DefaultRegisterer
+	}
+	if conf.Buckets == nil {
+		conf.Buckets = prom.DefBuckets
+	}
+
+	labels := prom.Labels{consumerLabel: conf.Consumer}
+	counter := func(name, help string) *prom.CounterVec {
+		return prom.NewCounterVec(prom.CounterOpts{Namespace: conf.Namespace, Name: name, Help: help, ConstLabels: labels},
+			[]string{queueLabel})
+	}
+	gauge := func(name, help string) *prom.GaugeVec {
+		return prom.NewGaugeVec(prom.GaugeOpts{Namespace: conf.Namespace, Name: name, Help: help, ConstLabels: labels},
+			[]string{queueLabel})
+	}
+	histogram := func(name, help string) *prom.HistogramVec {
+		return prom.NewHistogramVec(prom.HistogramOpts{Namespace: conf.Namespace, Name: name, Help: help,
+			ConstLabels: labels, Buckets: conf.Buckets}, []string{queueLabel})
+	}
+
+	r := &Recorder{
+		received:         counter("messages_received_total", "Messages received from the queue."),
+		processed:        counter("messages_processed_total", "Messages consumed without error."),
+		failed:           counter("messages_failed_total", "Messages whose consumer function failed."),
+		deleted:          counter("messages_deleted_total", "Messages deleted from the queue."),
+		processDuration:  histogram("process_duration_seconds", "Duration of the consumer function calls."),
+		queueLatency:     histogram("queue_latency_seconds", "Time the messages waited in the queue since they were sent."),
+		queueDepth:       gauge("queue_depth", "Visible, in flight and delayed messages of the queue, see SQSConf.QueueDepthInterval."),
+		oldestMessageAge: gauge("oldest_message_age_seconds", "Age of the oldest message of the queue, see SQSConf.OldestMessageAgeInterval."),
+		workQueueDepth:   gauge("work_queue_depth", "Messages dispatched to the group partitions and not consumed yet."),
+		pollerBlocked:    counter("poller_blocked_seconds_total", "Time the workers waited for a free partition or MaxInFlight slot."),
+	}
+
+	for _, c := range []prom.Collector{
+		r.received, r.processed, r.failed, r.deleted, r.processDuration, r.queueLatency,
+		r.queueDepth, r.oldestMessageAge, r.workQueueDepth, r.pollerBlocked,
+	} {
+		if err := conf.Registerer.Register(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+func (r *Recorder) MessagesReceived(queue string, n int) {
+	r.received.WithLabelValues(queue).Add(float64(n))
+}
+
+func (r *Recorder) QueueLatency(queue string, latency time.Duration) {
+	r.queueLatency.WithLabelValues(queue).Observe(latency.Seconds())
+}
+
+func (r *Recorder) MessagesProcessed(queue string, succeeded, failed int, elapsed time.Duration) {
+	r.processed.WithLabelValues(queue).Add(float64(succeeded))
+	r.failed.WithLabelValues(queue).Add(float64(failed))
+	r.processDuration.WithLabelValues(queue).Observe(elapsed.Seconds())
+}
+
+func (r *Recorder) MessagesDeleted(queue string, n int) {
+	r.deleted.WithLabelValues(queue).Add(float64(n))
+}
+
+func (r *Recorder) QueueDepth(queue string, depth int) {
+	r.queueDepth.WithLabelValues(queue).Set(float64(depth))
+}
+
+func (r *Recorder) OldestMessageAge(queue string, age time.Duration) {
+	r.oldestMessageAge.WithLabelValues(queue).Set(age.Seconds())
+}
+
+func (r *Recorder) WorkQueueDepth(queue string, depth int) {
+	r.workQueueDepth.WithLabelValues(queue).Set(float64(depth))
+}
+
+func (r *Recorder) PollerBlocked(queue string, waited time.Duration) {
+	r.pollerBlocked.WithLabelValues(queue).Add(waited.Seconds())
+}
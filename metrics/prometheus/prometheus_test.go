@@ -0,0 +1,56 @@
+package prometheus
+
+import (
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecorder(t *testing.T) {
+	reg := prom.NewRegistry()
+	r, err := NewRecorder(Config{Consumer: "billing", Registerer: reg, Buckets: []float64{0.01, 1}})
+	require.NoError(t, err)
+
+	r.MessagesReceived("orders", 3)
+	r.MessagesProcessed("orders", 2, 1, 5*time.Millisecond)
+	r.MessagesDeleted("orders", 2)
+	r.QueueDepth("orders", 42)
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP sqs_consume_messages_received_total Messages received from the queue.
+# TYPE sqs_consume_messages_received_total counter
+sqs_consume_messages_received_total{consumer="billing",queue="orders"} 3
+# HELP sqs_consume_messages_processed_total Messages consumed without error.
+# TYPE sqs_consume_messages_processed_total counter
+sqs_consume_messages_processed_total{consumer="billing",queue="orders"} 2
+# HELP sqs_consume_messages_failed_total Messages whose consumer function failed.
+# TYPE sqs_consume_messages_failed_total counter
+sqs_consume_messages_failed_total{consumer="billing",queue="orders"} 1
+# HELP sqs_consume_process_duration_seconds Duration of the consumer function calls.
+# TYPE sqs_consume_process_duration_seconds histogram
+sqs_consume_process_duration_seconds_bucket{consumer="billing",queue="orders",le="0.01"} 1
+sqs_consume_process_duration_seconds_bucket{consumer="billing",queue="orders",le="1"} 1
+sqs_consume_process_duration_seconds_bucket{consumer="billing",queue="orders",le="+Inf"} 1
+sqs_consume_process_duration_seconds_sum{consumer="billing",queue="orders"} 0.005
+sqs_consume_process_duration_seconds_count{consumer="billing",queue="orders"} 1
+# HELP sqs_consume_queue_depth Visible, in flight and delayed messages of the queue, see SQSConf.QueueDepthInterval.
+# TYPE sqs_consume_queue_depth gauge
+sqs_consume_queue_depth{consumer="billing",queue="orders"} 42
+`), "sqs_consume_messages_received_total", "sqs_consume_messages_processed_total", "sqs_consume_messages_failed_total",
+		"sqs_consume_process_duration_seconds", "sqs_consume_queue_depth"))
+}
+
+func TestNewRecorderPerConsumer(t *testing.T) {
+	reg := prom.NewRegistry()
+
+	_, err := NewRecorder(Config{Consumer: "billing", Registerer: reg})
+	require.NoError(t, err)
+	_, err = NewRecorder(Config{Consumer: "shipping", Registerer: reg})
+	assert.NoError(t, err)
+	_, err = NewRecorder(Config{Consumer: "billing", Registerer: reg})
+	assert.Error(t, err, "the collectors of billing are already registered")
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/ducksify/sqs-consume/consumer"
+	"time"
+)
+
+var (
+	// SentinelErrorPanic is consumer.SentinelErrorHandlerPanic, so that recovered panics match it wherever recovered.
+	SentinelErrorPanic = consumer.SentinelErrorHandlerPanic
+)
+
+// Timeout cancels the context of the consumer function once d elapsed.
+func Timeout(d time.Duration) consumer.Middleware {
+	return func(next consumer.ContextConsumerFn) consumer.ContextConsumerFn {
+		return func(ctx context.Context, data []byte, attributes map[string]types.MessageAttributeValue) error {
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+
+			return next(ctx, data, attributes)
+		}
+	}
+}
+
+// Retry calls the consumer function up to attempts times while it fails, waiting backoff before the first retry
+// and doubling the wait before each of the next ones. It stops retrying when the next attempt would start after
+// the consumer.RetryDeadline of the message or when consumer.AllowRetry denies it, returning the last error.
+func Retry(attempts int, backoff time.Duration) consumer.Middleware {
+	return func(next consumer.ContextConsumerFn) consumer.ContextConsumerFn {
+		return func(ctx context.Context, data []byte, attributes map[string]types.MessageAttributeValue) error {
+			err := next(ctx, data, attributes)
+
+			deadline, bounded := consumer.RetryDeadline(ctx)
+
+			for attempt, wait := 1, backoff; err != nil && attempt < attempts; attempt, wait = attempt+1, wait*2 {
+				if bounded && time.Now().Add(wait).After(deadline) {
+					return err
+				}
+				if !consumer.AllowRetry(ctx) {
+					return err
+				}
+
+				t := time.NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					t.Stop()
+					return errors.Join(err, ctx.Err())
+				case <-t.C:
+				}
+
+				err = next(ctx, data, attributes)
+			}
+
+			return err
+		}
+	}
+}
+
+// Recover turns a panic of the consumer function into an error wrapping SentinelErrorPanic.
+func Recover() consumer.Middleware {
+	return func(next consumer.ContextConsumerFn) consumer.ContextConsumerFn {
+		return func(ctx context.Context, data []byte, attributes map[string]types.MessageAttributeValue) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = fmt.Errorf("%w: %v", SentinelErrorPanic, r)
+				}
+			}()
+
+			return next(ctx, data, attributes)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/ducksify/sqs-consume/consumer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	consumeFn := consumer.Chain(func(ctx context.Context, data []byte, attributes map[string]types.MessageAttributeValue) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, Timeout(10*time.Millisecond))
+
+	start := time.Now()
+	err := consumeFn(context.Background(), []byte("msg1"), nil)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  int
+		attempts  int
+		wantCalls int
+		wantErr   bool
+	}{
+		{name: "shouldNotRetrySuccess", failures: 0, attempts: 3, wantCalls: 1},
+		{name: "shouldRetryUntilSuccess", failures: 2, attempts: 3, wantCalls: 3},
+		{name: "shouldStopAfterAttempts", failures: 5, attempts: 3, wantCalls: 3, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			consumeFn := consumer.Chain(func(ctx context.Context, data []byte, attributes map[string]types.MessageAttributeValue) error {
+				calls++
+				if calls <= tt.failures {
+					return errors.New("fake consume error")
+				}
+				return nil
+			}, Retry(tt.attempts, time.Millisecond))
+
+			err := consumeFn(context.Background(), []byte("msg1"), nil)
+			assert.Equal(t, tt.wantCalls, calls)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestRetryCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := 0
+	consumeFn := consumer.Chain(func(ctx context.Context, data []byte, attributes map[string]types.MessageAttributeValue) error {
+		calls++
+		return errors.New("fake consume error")
+	}, Retry(3, time.Hour))
+
+	err := consumeFn(ctx, []byte("msg1"), nil)
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, calls)
+}
+
+func TestRecover(t *testing.T) {
+	consumeFn := consumer.Chain(func(ctx context.Context, data []byte, attributes map[string]types.MessageAttributeValue) error {
+		panic("boom")
+	}, Recover(), Retry(2, time.Millisecond))
+
+	err := consumeFn(context.Background(), []byte("msg1"), nil)
+	require.ErrorIs(t, err, SentinelErrorPanic)
+	require.ErrorIs(t, err, consumer.SentinelErrorHandlerPanic)
+	assert.Contains(t, err.Error(), "boom")
+}